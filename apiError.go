@@ -0,0 +1,139 @@
+package binancepay
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// Binance pay response codes, doc https://developers.binance.com/docs/binance-pay/api-common#error-code
+const (
+	CodeSuccess                        = "000000"
+	CodeUnknownError                   = "400000"
+	CodeInvalidRequest                 = "400001"
+	CodeInvalidSignature               = "400002"
+	CodeInvalidTimestamp               = "400003"
+	CodeInvalidApiKeyOrIp              = "400004"
+	CodeBadApiKeyFmt                   = "400005"
+	CodeMandatoryParamEmptyOrMalformed = "400006"
+	CodeInvalidParamWrongLength        = "400007"
+	CodeInvalidParamWrongValue         = "400008"
+	CodeInvalidParamIllegalChar        = "400009"
+	CodeInvalidRequestTooLarge         = "400010"
+	CodeInvalidMerchantTradeNo         = "400011"
+	CodeTooManyRequests                = "400013" // the request quota was exceeded
+	CodeMerchantTradeNoDuplicated      = "400201"
+	CodeOrderNotFound                  = "400202"
+	CodeInvalidAccountStatus           = "400604"
+)
+
+// Sentinel errors of the known codes, to be used with errors.Is:
+//
+//	if errors.Is(err, binancepay.ErrOrderNotFound) { ... }
+var (
+	ErrUnknownError                   = &APIError{Code: CodeUnknownError, Message: "an unknown error occurred while processing the request"}
+	ErrInvalidRequest                 = &APIError{Code: CodeInvalidRequest, Message: "parameter format is wrong or parameter transferring doesn't follow the rules"}
+	ErrInvalidSignature               = &APIError{Code: CodeInvalidSignature, Message: "incorrect signature result"}
+	ErrInvalidTimestamp               = &APIError{Code: CodeInvalidTimestamp, Message: "timestamp for this request is outside of the time window"}
+	ErrInvalidApiKeyOrIp              = &APIError{Code: CodeInvalidApiKeyOrIp, Message: "api identity key not found or invalid"}
+	ErrBadApiKeyFmt                   = &APIError{Code: CodeBadApiKeyFmt, Message: "api identity key format invalid"}
+	ErrMandatoryParamEmptyOrMalformed = &APIError{Code: CodeMandatoryParamEmptyOrMalformed, Message: "a parameter was missing, empty or malformed"}
+	ErrInvalidParamWrongLength        = &APIError{Code: CodeInvalidParamWrongLength, Message: "a parameter has invalid length"}
+	ErrInvalidParamWrongValue         = &APIError{Code: CodeInvalidParamWrongValue, Message: "a parameter has invalid value"}
+	ErrInvalidParamIllegalChar        = &APIError{Code: CodeInvalidParamIllegalChar, Message: "a parameter contains illegal characters"}
+	ErrInvalidRequestTooLarge         = &APIError{Code: CodeInvalidRequestTooLarge, Message: "request content is too large"}
+	ErrInvalidMerchantTradeNo         = &APIError{Code: CodeInvalidMerchantTradeNo, Message: "merchantTradeNo is invalid"}
+	ErrMerchantTradeNoDuplicated      = &APIError{Code: CodeMerchantTradeNoDuplicated, Message: "merchantTradeNo is duplicated"}
+	ErrOrderNotFound                  = &APIError{Code: CodeOrderNotFound, Message: "order not found"}
+	ErrInvalidAccountStatus           = &APIError{Code: CodeInvalidAccountStatus, Message: "not supported for this account, please check account status"}
+
+	// ErrTooManyRequests matches responses rejected with http status 429.
+	ErrTooManyRequests = &APIError{HTTPStatus: http.StatusTooManyRequests, Message: "too many requests"}
+)
+
+var knownAPIErrors = map[string]*APIError{}
+
+func init() {
+	for _, err := range []*APIError{
+		ErrUnknownError, ErrInvalidRequest, ErrInvalidSignature, ErrInvalidTimestamp, ErrInvalidApiKeyOrIp,
+		ErrBadApiKeyFmt, ErrMandatoryParamEmptyOrMalformed, ErrInvalidParamWrongLength, ErrInvalidParamWrongValue,
+		ErrInvalidParamIllegalChar, ErrInvalidRequestTooLarge, ErrInvalidMerchantTradeNo, ErrMerchantTradeNoDuplicated,
+		ErrOrderNotFound, ErrInvalidAccountStatus,
+	} {
+		knownAPIErrors[err.Code] = err
+	}
+}
+
+// LookupErrorCode returns the sentinel error of a known code.
+func LookupErrorCode(code string) (*APIError, bool) {
+	err, ok := knownAPIErrors[code]
+	return err, ok
+}
+
+// APIError is returned by Merchant.Do when binance pay rejects a request.
+type APIError struct {
+	Status     string // "FAIL" for rejected requests, empty when the response body could not be decoded
+	Code       string
+	Message    string
+	HTTPStatus int           // 0 when unknown
+	Nonce      string        // BinancePay-Nonce of the rejected request
+	RetryAfter time.Duration // Retry-After of a throttled request, 0 when absent
+}
+
+func (e *APIError) Error() string {
+	if e.Status == "" && e.Code == "" {
+		return fmt.Sprintf("resp http status=%d %s", e.HTTPStatus, e.Message)
+	}
+	return fmt.Sprintf("resp status=%s code=%s errorMessage=%s", e.Status, e.Code, e.Message)
+}
+
+// Is reports whether target is an *APIError with the same code, or with the same http status when target has no code.
+// It makes the sentinel errors work with errors.Is.
+func (e *APIError) Is(target error) bool {
+	t, ok := target.(*APIError)
+	if !ok {
+		return false
+	}
+	if t.Code != "" {
+		return e.Code == t.Code
+	}
+	return t.HTTPStatus != 0 && e.HTTPStatus == t.HTTPStatus
+}
+
+// IsRetryable reports whether err is a transient failure worth retrying.
+func IsRetryable(err error) bool {
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+	return apiErr.HTTPStatus >= http.StatusInternalServerError ||
+		apiErr.HTTPStatus == http.StatusTooManyRequests ||
+		apiErr.Code == CodeTooManyRequests ||
+		apiErr.Code == CodeUnknownError
+}
+
+// IsRateLimited reports whether err was caused by exceeding the request quota,
+// signaled by http status 429 or the CodeTooManyRequests code.
+func IsRateLimited(err error) bool {
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+	return apiErr.HTTPStatus == http.StatusTooManyRequests || apiErr.Code == CodeTooManyRequests
+}
+
+// IsNotFound reports whether err was caused by an unknown order.
+func IsNotFound(err error) bool {
+	return errors.Is(err, ErrOrderNotFound)
+}
+
+// IsDuplicateOrder reports whether err was caused by reusing a merchantTradeNo.
+func IsDuplicateOrder(err error) bool {
+	return errors.Is(err, ErrMerchantTradeNoDuplicated)
+}
+
+// IsSignatureError reports whether err was caused by an invalid request signature.
+func IsSignatureError(err error) bool {
+	return errors.Is(err, ErrInvalidSignature)
+}
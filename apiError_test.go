@@ -0,0 +1,61 @@
+package binancepay
+
+import (
+	"errors"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestDoReturnsAPIError(t *testing.T) {
+	client := NewMerchant("", "", nil, logger, WithNonceFunc(func() string { return "fixed-nonce" }))
+	client.httpClient = mockHttpClient(func(request *http.Request) (*http.Response, error) {
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       ioutil.NopCloser(strings.NewReader(`{"status":"FAIL","code":"400202","errorMessage":"Order not found."}`)),
+		}, nil
+	})
+
+	var resp Response[QueryOrderResult]
+	err := client.Do(&QueryOrderRequest{MerchantTradeNo: "9825382937292"}, &resp)
+
+	var apiErr *APIError
+	assert.True(t, errors.As(err, &apiErr))
+	assert.Equal(t, "FAIL", apiErr.Status)
+	assert.Equal(t, CodeOrderNotFound, apiErr.Code)
+	assert.Equal(t, "Order not found.", apiErr.Message)
+	assert.Equal(t, http.StatusOK, apiErr.HTTPStatus)
+	assert.Equal(t, "fixed-nonce", apiErr.Nonce)
+	assert.Equal(t, "resp status=FAIL code=400202 errorMessage=Order not found.", err.Error())
+
+	assert.True(t, errors.Is(err, ErrOrderNotFound))
+	assert.True(t, IsNotFound(err))
+	assert.False(t, IsDuplicateOrder(err))
+	assert.False(t, IsRetryable(err))
+}
+
+func TestAPIErrorClassifiers(t *testing.T) {
+	wrap := func(err error) error {
+		return fmt.Errorf("createOrder(): %w", err)
+	}
+
+	assert.True(t, IsDuplicateOrder(wrap(&APIError{Status: "FAIL", Code: CodeMerchantTradeNoDuplicated})))
+	assert.True(t, IsSignatureError(wrap(&APIError{Status: "FAIL", Code: CodeInvalidSignature})))
+	assert.True(t, IsRetryable(wrap(&APIError{Status: "FAIL", Code: CodeUnknownError})))
+	assert.True(t, IsRetryable(wrap(&APIError{HTTPStatus: http.StatusBadGateway})))
+	assert.True(t, IsRateLimited(wrap(&APIError{HTTPStatus: http.StatusTooManyRequests})))
+	assert.True(t, IsRateLimited(wrap(&APIError{Status: "FAIL", Code: CodeTooManyRequests, HTTPStatus: http.StatusOK})))
+	assert.False(t, IsRateLimited(wrap(&APIError{Status: "FAIL", Code: CodeOrderNotFound})))
+	assert.True(t, errors.Is(wrap(&APIError{HTTPStatus: http.StatusTooManyRequests}), ErrTooManyRequests))
+	assert.False(t, errors.Is(wrap(&APIError{HTTPStatus: http.StatusOK, Code: CodeOrderNotFound}), ErrTooManyRequests))
+	assert.False(t, IsRetryable(errors.New("json.Unmarshal(respBytes): unexpected end of JSON input")))
+
+	known, ok := LookupErrorCode(CodeInvalidSignature)
+	assert.True(t, ok)
+	assert.Equal(t, ErrInvalidSignature, known)
+	_, ok = LookupErrorCode("999999")
+	assert.False(t, ok)
+}
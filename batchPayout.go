@@ -0,0 +1,294 @@
+package binancepay
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/go-playground/validator/v10"
+	"github.com/shopspring/decimal"
+	"sync"
+)
+
+var _ IRequest = &BatchPayoutRequest{}
+
+// MaxPayoutBatchSize is the maximum length of transferDetailList accepted by a single payout request.
+const MaxPayoutBatchSize = 1000
+
+// maxPayoutRequestIdLen is the maximum length of the requestId of a payout request.
+const maxPayoutRequestIdLen = 32
+
+const (
+	PayoutReceiveTypePayId     = "PAY_ID"
+	PayoutReceiveTypeBinanceId = "BINANCE_ID"
+	PayoutReceiveTypeEmail     = "EMAIL"
+)
+
+type TransferDetail struct {
+	MerchantSendId string          `json:"merchantSendId" validate:"required,max=32"` // The unique ID assigned by the merchant to identify a detail transfer.
+	ReceiveType    string          `json:"receiveType" validate:"required,oneof=PAY_ID BINANCE_ID EMAIL"`
+	Receiver       string          `json:"receiver" validate:"required"`
+	TransferAmount decimal.Decimal `json:"transferAmount" validate:"required"`
+	TransferMethod string          `json:"transferMethod,omitempty" validate:"omitempty,oneof=FUNDING_WALLET SPOT_WALLET"` // Optional. FUNDING_WALLET by default.
+	Remark         string          `json:"remark,omitempty" validate:"max=128"`                                            // Optional.
+}
+
+// Money returns the TransferAmount in currency, the Currency of the BatchPayoutRequest.
+func (d *TransferDetail) Money(currency string) Money {
+	return NewMoney(d.TransferAmount, currency)
+}
+
+// BatchPayoutRequest doc https://developers.binance.com/docs/binance-pay/api-payout
+type BatchPayoutRequest struct {
+	RequestId          string           `json:"requestId" validate:"required,max=32"` // The unique ID assigned by the merchant to identify a payout request.
+	BizScene           string           `json:"bizScene,omitempty"`                   // Optional. e.g. "DIRECT_TRANSFER", "REWARD"
+	BatchName          string           `json:"batchName" validate:"required,max=128"`
+	Currency           string           `json:"currency" validate:"required"`
+	TotalAmount        decimal.Decimal  `json:"totalAmount" validate:"required,precision=Currency"` // Must equal the sum of transferAmount in transferDetailList
+	TotalNumber        int              `json:"totalNumber" validate:"required,min=1,max=1000"`
+	TransferDetailList []TransferDetail `json:"transferDetailList" validate:"required,min=1,max=1000,dive"`
+}
+
+func (r *BatchPayoutRequest) EndPoint() string {
+	return "/binancepay/openapi/payout/transfer"
+}
+
+func (r *BatchPayoutRequest) Validate() error {
+	return validateStruct(r)
+}
+
+// Money returns the TotalAmount in Currency.
+func (r *BatchPayoutRequest) Money() Money {
+	return NewMoney(r.TotalAmount, r.Currency)
+}
+
+// validateBatchPayoutRequest checks the precision of the transfer amounts, which are in the Currency of the batch.
+func validateBatchPayoutRequest(sl validator.StructLevel) {
+	r := sl.Current().Interface().(BatchPayoutRequest)
+	for i, detail := range r.TransferDetailList {
+		if detail.Money(r.Currency).Validate() != nil {
+			sl.ReportError(detail.TransferAmount, fmt.Sprintf("transferDetailList[%d].transferAmount", i),
+				fmt.Sprintf("TransferDetailList[%d].TransferAmount", i), "precision", "Currency")
+		}
+	}
+}
+
+type BatchPayoutResult struct {
+	RequestId string `json:"requestId"`
+	Status    string `json:"status"` // ACCEPTED, PROCESSING, SUCCESS, PART_SUCCESS, FAILED, CANCELED
+}
+
+var _ IRequest = &QueryPayoutRequest{}
+
+// QueryPayoutRequest doc https://developers.binance.com/docs/binance-pay/api-payout-query
+type QueryPayoutRequest struct {
+	RequestId    string `json:"requestId" validate:"required"`
+	DetailStatus string `json:"detailStatus,omitempty"` // Optional. Filters transferDetailList by status, e.g. "SUCCESS", "FAILED"
+}
+
+func (q *QueryPayoutRequest) EndPoint() string {
+	return "/binancepay/openapi/payout/query"
+}
+
+func (q *QueryPayoutRequest) Validate() error {
+	return validateStruct(q)
+}
+
+func (q *QueryPayoutRequest) Idempotent() bool {
+	return true
+}
+
+type QueryPayoutResult struct {
+	RequestId          string                       `json:"requestId"`
+	BatchStatus        string                       `json:"batchStatus"`
+	MerchantId         string                       `json:"merchantId"`
+	Currency           string                       `json:"currency"`
+	TotalAmount        decimal.Decimal              `json:"totalAmount"`
+	TotalNumber        int                          `json:"totalNumber"`
+	TransferDetailList []PayoutTransferDetailResult `json:"transferDetailList"`
+}
+
+// Money returns the TotalAmount in Currency.
+func (r *QueryPayoutResult) Money() Money {
+	return NewMoney(r.TotalAmount, r.Currency)
+}
+
+type PayoutTransferDetailResult struct {
+	MerchantSendId string          `json:"merchantSendId"`
+	Receiver       string          `json:"receiver"`
+	TransferAmount decimal.Decimal `json:"transferAmount"`
+	Status         string          `json:"status"`
+	TransferMethod string          `json:"transferMethod"`
+	Remark         string          `json:"remark"`
+}
+
+// Money returns the TransferAmount in currency, the Currency of the QueryPayoutResult.
+func (r *PayoutTransferDetailResult) Money(currency string) Money {
+	return NewMoney(r.TransferAmount, currency)
+}
+
+// SplitPayout splits a payout with an arbitrary number of transfer details into requests holding at most
+// batchSize details each. When more than one request is produced, "B<n>" is appended to requestId, binance pay
+// accepts only letters and digits there. requestId must leave room for the suffix within 32 characters,
+// otherwise an error is returned.
+func SplitPayout(req *BatchPayoutRequest, batchSize int) ([]*BatchPayoutRequest, error) {
+	if batchSize <= 0 || batchSize > MaxPayoutBatchSize {
+		batchSize = MaxPayoutBatchSize
+	}
+
+	var batches []*BatchPayoutRequest
+	for start := 0; start < len(req.TransferDetailList); start += batchSize {
+		end := start + batchSize
+		if end > len(req.TransferDetailList) {
+			end = len(req.TransferDetailList)
+		}
+		details := req.TransferDetailList[start:end]
+
+		total := decimal.Zero
+		for _, d := range details {
+			total = total.Add(d.TransferAmount)
+		}
+
+		batch := *req
+		batch.TotalAmount = total
+		batch.TotalNumber = len(details)
+		batch.TransferDetailList = details
+		batches = append(batches, &batch)
+	}
+
+	if len(batches) > 1 {
+		for i, batch := range batches {
+			batch.RequestId = fmt.Sprintf("%sB%d", req.RequestId, i+1)
+			if len(batch.RequestId) > maxPayoutRequestIdLen {
+				return nil, fmt.Errorf("requestId %s of batch %d exceeds %d characters", batch.RequestId, i+1, maxPayoutRequestIdLen)
+			}
+		}
+	}
+
+	return batches, nil
+}
+
+// PayoutBatch is the state of a single payout request submitted by Merchant.BatchPayout.
+type PayoutBatch struct {
+	RequestId   string
+	TotalAmount decimal.Decimal
+	TotalNumber int
+	Status      string // the status returned by the transfer API, later replaced by PayoutNoti.BatchStatus
+	Err         error  // set when the batch could not be submitted
+}
+
+// PayoutTracker correlates PayoutNoti webhooks with the batches submitted by Merchant.BatchPayout.
+// It is safe for concurrent use.
+type PayoutTracker struct {
+	mu      sync.RWMutex
+	batches []*PayoutBatch
+	index   map[string]*PayoutBatch
+}
+
+func newPayoutTracker() *PayoutTracker {
+	return &PayoutTracker{
+		index: map[string]*PayoutBatch{},
+	}
+}
+
+func (t *PayoutTracker) add(batch *PayoutBatch) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.batches = append(t.batches, batch)
+	t.index[batch.RequestId] = batch
+}
+
+// HandleNoti updates the status of the batch the notification belongs to.
+// It returns false when the notification does not belong to any tracked batch.
+func (t *PayoutTracker) HandleNoti(noti *PayoutNoti) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	batch, ok := t.index[noti.RequestId]
+	if !ok {
+		return false
+	}
+	batch.Status = noti.BatchStatus
+	return true
+}
+
+// Batches returns a snapshot of all tracked batches in submission order.
+func (t *PayoutTracker) Batches() []PayoutBatch {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	batches := make([]PayoutBatch, 0, len(t.batches))
+	for _, batch := range t.batches {
+		batches = append(batches, *batch)
+	}
+	return batches
+}
+
+// Batch returns a snapshot of the batch with the given requestId.
+func (t *PayoutTracker) Batch(requestId string) (PayoutBatch, bool) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	batch, ok := t.index[requestId]
+	if !ok {
+		return PayoutBatch{}, false
+	}
+	return *batch, true
+}
+
+// Done reports whether every batch was either rejected or reached a final status.
+func (t *PayoutTracker) Done() bool {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	for _, batch := range t.batches {
+		if batch.Err == nil && !isFinalPayoutStatus(batch.Status) {
+			return false
+		}
+	}
+	return true
+}
+
+func isFinalPayoutStatus(status string) bool {
+	switch status {
+	case "SUCCESS", "PART_SUCCESS", "FAILED", "CANCELED":
+		return true
+	}
+	return false
+}
+
+// BatchPayout is BatchPayoutContext with context.Background().
+func (m *Merchant) BatchPayout(req *BatchPayoutRequest, batchSize int) (*PayoutTracker, error) {
+	return m.BatchPayoutContext(context.Background(), req, batchSize)
+}
+
+// BatchPayoutContext splits req into API sized batches (see SplitPayout) and submits them one by one.
+// Submission continues when a batch fails, the failure is recorded in the batch and joined into the returned error.
+// Feed PayoutNoti webhooks into the returned tracker to follow the batches until they are done.
+func (m *Merchant) BatchPayoutContext(ctx context.Context, req *BatchPayoutRequest, batchSize int) (*PayoutTracker, error) {
+	if len(req.TransferDetailList) == 0 {
+		return nil, fmt.Errorf("empty transferDetailList")
+	}
+
+	batches, err := SplitPayout(req, batchSize)
+	if err != nil {
+		return nil, fmt.Errorf("SplitPayout(): %w", err)
+	}
+
+	tracker := newPayoutTracker()
+
+	var errs []error
+	for _, batchReq := range batches {
+		batch := &PayoutBatch{
+			RequestId:   batchReq.RequestId,
+			TotalAmount: batchReq.TotalAmount,
+			TotalNumber: batchReq.TotalNumber,
+		}
+
+		var resp Response[BatchPayoutResult]
+		if err := m.DoContext(ctx, batchReq, &resp); err != nil {
+			batch.Err = err
+			errs = append(errs, fmt.Errorf("payout %s: %w", batchReq.RequestId, err))
+		} else {
+			batch.Status = resp.Data.Status
+		}
+		tracker.add(batch)
+	}
+
+	return tracker, errors.Join(errs...)
+}
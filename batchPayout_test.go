@@ -0,0 +1,114 @@
+package binancepay
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"net/http"
+	"testing"
+)
+
+func testPayoutRequest(n int) *BatchPayoutRequest {
+	req := &BatchPayoutRequest{
+		RequestId: "payout20261018",
+		BatchName: "rewards",
+		Currency:  "USDT",
+	}
+	for i := 0; i < n; i++ {
+		req.TransferDetailList = append(req.TransferDetailList, TransferDetail{
+			MerchantSendId: fmt.Sprintf("send%d", i),
+			ReceiveType:    PayoutReceiveTypeBinanceId,
+			Receiver:       fmt.Sprintf("%d", 100000+i),
+			TransferAmount: decimal.RequireFromString("1.5"),
+		})
+	}
+	return req
+}
+
+func TestSplitPayout(t *testing.T) {
+	batches, err := SplitPayout(testPayoutRequest(5), 2)
+	assert.Nil(t, err, err)
+	assert.Len(t, batches, 3)
+	assert.Nil(t, batches[0].Validate())
+	assert.Equal(t, "payout20261018B1", batches[0].RequestId)
+	assert.Equal(t, "payout20261018B3", batches[2].RequestId)
+	assert.Equal(t, 2, batches[0].TotalNumber)
+	assert.Equal(t, 1, batches[2].TotalNumber)
+	assert.Equal(t, "3", batches[0].TotalAmount.String())
+	assert.Equal(t, "1.5", batches[2].TotalAmount.String())
+	assert.Equal(t, "send4", batches[2].TransferDetailList[0].MerchantSendId)
+
+	single, err := SplitPayout(testPayoutRequest(3), 0)
+	assert.Nil(t, err, err)
+	assert.Len(t, single, 1)
+	assert.Equal(t, "payout20261018", single[0].RequestId)
+	assert.Equal(t, 3, single[0].TotalNumber)
+
+	// no room for the suffix
+	req := testPayoutRequest(5)
+	req.RequestId = "payout2026101800000000000000000"
+	_, err = SplitPayout(req, 2)
+	assert.NotNil(t, err)
+}
+
+func TestQueryPayout(t *testing.T) {
+	req := &QueryPayoutRequest{RequestId: "payout20261018"}
+	expectedResp := Response[QueryPayoutResult]{
+		Status: "SUCCESS",
+		Code:   "000000",
+		Data: QueryPayoutResult{
+			RequestId:   "payout20261018",
+			BatchStatus: "SUCCESS",
+		},
+	}
+	client := NewMerchant("", "", nil, logger)
+	client.httpClient = mockHttpClientWithAsserts(t, "POST", "/binancepay/openapi/payout/query", req, expectedResp)
+	var resp Response[QueryPayoutResult]
+	err := client.Do(req, &resp)
+	assert.Nil(t, err, err)
+	assert.Equal(t, "SUCCESS", resp.Data.BatchStatus)
+}
+
+func TestBatchPayout(t *testing.T) {
+	client := NewMerchant("", "", nil, logger)
+	client.httpClient = mockHttpClient(func(request *http.Request) (*http.Response, error) {
+		assert.Equal(t, "/binancepay/openapi/payout/transfer", request.URL.Path)
+
+		var req BatchPayoutRequest
+		err := json.NewDecoder(request.Body).Decode(&req)
+		assert.Nil(t, err, err)
+
+		resp := Response[BatchPayoutResult]{Status: "SUCCESS", Code: "000000", Data: BatchPayoutResult{RequestId: req.RequestId, Status: "ACCEPTED"}}
+		if req.RequestId == "payout20261018B2" {
+			resp = Response[BatchPayoutResult]{Status: "FAIL", Code: "400001", ErrMsg: "invalid request"}
+		}
+		respBody, err := json.Marshal(resp)
+		assert.Nil(t, err, err)
+		return &http.Response{
+			Body: ioutil.NopCloser(bytes.NewReader(respBody)),
+		}, nil
+	})
+
+	tracker, err := client.BatchPayout(testPayoutRequest(5), 2)
+	assert.NotNil(t, err)
+	assert.Len(t, tracker.Batches(), 3)
+
+	batch, ok := tracker.Batch("payout20261018B1")
+	assert.True(t, ok)
+	assert.Equal(t, "ACCEPTED", batch.Status)
+	batch, _ = tracker.Batch("payout20261018B2")
+	assert.NotNil(t, batch.Err)
+	assert.False(t, tracker.Done())
+
+	assert.True(t, tracker.HandleNoti(&PayoutNoti{RequestId: "payout20261018B1", BatchStatus: "SUCCESS"}))
+	assert.False(t, tracker.Done())
+	assert.True(t, tracker.HandleNoti(&PayoutNoti{RequestId: "payout20261018B3", BatchStatus: "PART_SUCCESS"}))
+	assert.False(t, tracker.HandleNoti(&PayoutNoti{RequestId: "unknown", BatchStatus: "SUCCESS"}))
+	assert.True(t, tracker.Done())
+
+	batch, _ = tracker.Batch("payout20261018B3")
+	assert.Equal(t, "PART_SUCCESS", batch.Status)
+}
@@ -7,11 +7,14 @@ package binancepay
 import (
 	"bytes"
 	"context"
-	"crypto/rsa"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
+	"golang.org/x/sync/singleflight"
 	"io/ioutil"
 	"net/http"
 	"sync/atomic"
@@ -33,24 +36,68 @@ type Merchant struct {
 	secret     []byte
 	logger     *zap.Logger
 	httpClient *http.Client
+	now        func() time.Time
+	nonce      func() string
 
-	cert          *Certificate
-	certPublicKey *rsa.PublicKey
+	certs               certKeys
+	certFlight          singleflight.Group
+	certRefreshInterval time.Duration
+	preloadedCerts      []Certificate
 
 	requestID uint64
 	cache     Cache // store certificate
+
+	retryPolicy RetryPolicy
+	limiter     *rateLimiter
+
+	interceptors []Interceptor
+	invoker      Invoker
+
+	redactedFields   []string
+	metadataOnlyLogs bool
+	redactor         *redactor
+
+	tracerProvider trace.TracerProvider
+	meterProvider  metric.MeterProvider
+	telemetry      *telemetry
+
+	webhookWindow time.Duration
+	nonceStore    NonceStore
+
+	createOrderStore CreateOrderStore
 }
 
-func NewMerchant(apiKey, secret string, cache Cache, logger *zap.Logger) *Merchant {
-	return &Merchant{
+func NewMerchant(apiKey, secret string, cache Cache, logger *zap.Logger, opts ...Option) *Merchant {
+	m := &Merchant{
 		host:       DefaultHost,
 		apiKey:     apiKey,
 		secret:     []byte(secret),
 		logger:     logger,
 		httpClient: http.DefaultClient,
+		now:        time.Now,
+		nonce:      Nonce,
 		requestID:  0,
-		cache:      cache,
+
+		certRefreshInterval: DefaultCertRefreshInterval,
+		cache:               cache,
+	}
+	for _, opt := range opts {
+		opt(m)
+	}
+	if m.logger == nil {
+		m.logger = zap.NewNop()
+	}
+	m.invoker = chainInterceptors(m.interceptors, m.send)
+	m.redactor = newRedactor(m.redactedFields, m.metadataOnlyLogs)
+	var err error
+	if m.telemetry, err = newTelemetry(m.tracerProvider, m.meterProvider); err != nil {
+		m.logger.Error("failed to create metric instruments, telemetry is disabled", zap.Error(err))
+		m.telemetry, _ = newTelemetry(nil, nil)
 	}
+	if len(m.preloadedCerts) > 0 {
+		m.preloadCertificates()
+	}
+	return m
 }
 
 type NotiBizType string
@@ -59,7 +106,9 @@ type webhookRawReq struct {
 	BizType   NotiBizType `json:"bizType"`
 	BizId     json.Number `json:"bizId"`
 	RawData   string      `json:"data"`
-	BizStatus string      `json:"bizStatus"`
+	BizStatus BizStatus   `json:"bizStatus"`
+
+	nonce string // Binancepay-Nonce
 }
 
 type IResponse interface {
@@ -82,13 +131,31 @@ func (r *Response[T]) GetError() error {
 	if r.Success() {
 		return nil
 	}
-	return fmt.Errorf("resp status=%s code=%s errorMessage=%s", r.Status, r.Code, r.ErrMsg)
+	return &APIError{
+		Status:  r.Status,
+		Code:    r.Code,
+		Message: r.ErrMsg,
+	}
 }
 
+// VerifyAndParseWebhookRequest is VerifyAndParseWebhookRequestContext with the context of r.
 func (m *Merchant) VerifyAndParseWebhookRequest(r *http.Request) (*webhookRawReq, error) {
+	return m.VerifyAndParseWebhookRequestContext(r.Context(), r)
+}
+
+// VerifyAndParseWebhookRequestContext verifies the signature of a webhook request,
+// ctx is used to load the binance certificates.
+func (m *Merchant) VerifyAndParseWebhookRequestContext(ctx context.Context, r *http.Request) (_ *webhookRawReq, err error) {
 	timestamp := r.Header.Get("Binancepay-Timestamp")
 	nonce := r.Header.Get("Binancepay-Nonce")
 	signatureStr := r.Header.Get("Binancepay-Signature")
+	serial := r.Header.Get("BinancePay-Certificate-SN")
+
+	ctx, span := m.telemetry.tracer.Start(ctx, SpanVerifyWebhook,
+		trace.WithSpanKind(trace.SpanKindServer),
+		trace.WithAttributes(AttrCertSerial.String(serial)),
+	)
+	defer func() { endSpan(span, err) }()
 
 	entityBody, err := ioutil.ReadAll(r.Body)
 	if err != nil {
@@ -98,46 +165,18 @@ func (m *Merchant) VerifyAndParseWebhookRequest(r *http.Request) (*webhookRawReq
 	m.logger.Debug("verify and parse webhook request",
 		zap.String("Binancepay-Timestamp", timestamp),
 		zap.String("Binancepay-Nonce", nonce),
-		zap.String("Binancepay-Signature", signatureStr),
-		zap.ByteString("body", entityBody),
+		zap.String("Binancepay-Signature", m.redactor.secret(signatureStr)),
+		zap.String("BinancePay-Certificate-SN", serial),
+		m.redactor.body("body", entityBody),
 	)
 
-	// Load certificates
-	if m.certPublicKey == nil {
-		m.logger.Debug("load binance cert")
-
-		cacheKey := "binance-pay:cert:" + m.apiKey
-		var cert Certificate
-		exists, err := m.cache.GetJSON(context.TODO(), cacheKey, &cert)
-		if err != nil {
-			m.logger.Error("failed to get binance cert from cache", zap.Error(err))
-			return nil, fmt.Errorf("queryCertificatesFromCache(): %w", err)
-		}
-
-		if !exists {
-			req := &QueryCertificateRequest{}
-			var resp Response[QueryCertificateResult]
-			if err = m.Do(req, &resp); err != nil {
-				m.logger.Error("failed to get binance cert from query certificates API", zap.Error(err))
-				return nil, fmt.Errorf("queryCertificates(): %w", err)
-			}
-			if len(resp.Data) == 0 {
-				return nil, fmt.Errorf("got empty certificates response")
-			}
-			cert.CertSerial = resp.Data[0].CertSerial
-			cert.CertPublic = resp.Data[0].CertPublic
-
-			if err = m.cache.SetJSON(context.TODO(), cacheKey, cert, time.Hour*24*365); err != nil {
-				return nil, fmt.Errorf("cacheCertificate(): %w", err)
-			}
-		}
+	if err = m.checkWebhookTimestamp(timestamp); err != nil {
+		return nil, err
+	}
 
-		pub, err := ParsePublicKey(cert.CertPublic)
-		if err != nil {
-			return nil, fmt.Errorf("ParsePublicKey(): %w", err)
-		}
-		m.cert = &cert
-		m.certPublicKey = pub
+	keys, err := m.webhookPublicKeys(ctx, serial)
+	if err != nil {
+		return nil, err
 	}
 
 	signature, err := base64.StdEncoding.DecodeString(signatureStr)
@@ -147,16 +186,35 @@ func (m *Merchant) VerifyAndParseWebhookRequest(r *http.Request) (*webhookRawReq
 
 	payload := BuildPayload(string(entityBody), timestamp, nonce)
 
-	err = verifySignature(m.certPublicKey, []byte(payload), signature)
+	err = verifySignatureWithAny(keys, []byte(payload), signature)
+	if err != nil {
+		// binance may have rotated its certificate
+		refreshed, refreshErr := m.refreshCertificates(ctx)
+		if refreshErr != nil {
+			m.logger.Warn("failed to refresh binance certs", zap.Error(refreshErr))
+		}
+		if refreshed {
+			// the serial may be gone from the refetched certificates
+			if keys, loaded := m.candidatePublicKeys(serial); loaded && len(keys) > 0 {
+				err = verifySignatureWithAny(keys, []byte(payload), signature)
+			}
+		}
+	}
 	if err != nil {
 		return nil, fmt.Errorf("verifySignature(): %w", err)
 	}
 
-	request := webhookRawReq{}
+	request := webhookRawReq{nonce: nonce}
 	if err = json.Unmarshal(entityBody, &request); err != nil {
 		return nil, fmt.Errorf("json.Unmarshal(): %w", err)
 	}
 
+	// nonces are recorded only for authentic webhooks, so forged requests can't exhaust the store
+	if err = m.checkWebhookNonce(ctx, nonce); err != nil {
+		return nil, err
+	}
+	span.SetAttributes(AttrBizType.String(string(request.BizType)), AttrBizStatus.String(string(request.BizStatus)))
+
 	return &request, nil
 }
 
@@ -185,14 +243,35 @@ type IRequest interface {
 	Validate() error
 }
 
+// validatorAt is implemented by requests with rules depending on the time, e.g. CreateOrderV2Request.
+type validatorAt interface {
+	ValidateAt(now time.Time) error
+}
+
+// validateRequest validates req at the time of the clock of m.
+func (m *Merchant) validateRequest(req IRequest) error {
+	if v, ok := req.(validatorAt); ok {
+		return v.ValidateAt(m.now())
+	}
+	return req.Validate()
+}
+
 type HttpMethodProvider interface {
 	HttpMethod() string
 }
 
-func (m *Merchant) Do(req IRequest, response IResponse) (err error) {
+// Do is DoContext with context.Background().
+func (m *Merchant) Do(req IRequest, response IResponse) error {
+	return m.DoContext(context.Background(), req, response)
+}
+
+// DoContext signs and sends req, the decoded response is stored into response.
+// The request is canceled when ctx is done.
+// Failed attempts are retried according to the RetryPolicy of the merchant, see WithRetryPolicy.
+func (m *Merchant) DoContext(ctx context.Context, req IRequest, response IResponse) (err error) {
 	logger := m.logger.With(zap.Uint64("id", atomic.AddUint64(&m.requestID, 1)))
 
-	if err = req.Validate(); err != nil {
+	if err = m.validateRequest(req); err != nil {
 		return err
 	}
 
@@ -201,45 +280,132 @@ func (m *Merchant) Do(req IRequest, response IResponse) (err error) {
 		return fmt.Errorf("req.MarshalJSON(): %w", err)
 	}
 
-	nonce := Nonce()
-	timestampMilli := fmt.Sprintf("%d", time.Now().UnixMilli())
-	payload := BuildPayload(string(body), timestampMilli, nonce)
+	method := "POST"
+	if httpMethodProvider, ok := req.(HttpMethodProvider); ok {
+		method = httpMethodProvider.HttpMethod()
+	}
+
+	attempts := 0
+	ctx, endTelemetry := m.telemetry.startRequest(ctx, req.EndPoint(), body)
+	defer func() { endTelemetry(attempts, err) }()
+
+	maxAttempts := m.retryPolicy.attempts(req)
+	throttled := 0
+	for attempt := 1; ; attempt++ {
+		attemptLogger := logger.With(zap.Int("attempt", attempt))
+		attempts = attempt
+
+		if m.limiter != nil {
+			if waitErr := m.limiter.wait(ctx, m.now(), req.EndPoint()); waitErr != nil {
+				if err != nil {
+					return err
+				}
+				return fmt.Errorf("limiter.wait(): %w", waitErr)
+			}
+		}
+
+		call := &Call{
+			Request:  req,
+			Attempt:  attempt,
+			Method:   method,
+			URL:      m.host + req.EndPoint(),
+			Body:     body,
+			Response: response,
+			logger:   attemptLogger,
+		}
+		if err = m.sign(call); err != nil {
+			return err
+		}
+		err = m.invoker(ctx, call)
+		retryable := call.retryable || m.retryPolicy.retryable(err)
+		if m.limiter != nil {
+			m.limiter.done(m.now(), req.EndPoint(), err)
+			// a throttled request was not processed, so it's resent once the limiter allows it
+			if IsRateLimited(err) && throttled < maxThrottledAttempts {
+				throttled++
+				attemptLogger.Warn("request throttled", zap.Error(err))
+				continue
+			}
+		}
+		if err == nil {
+			return nil
+		}
+		if !retryable || attempt-throttled >= maxAttempts {
+			return err
+		}
+
+		delay := m.retryPolicy.backoff(attempt)
+		attemptLogger.Warn("retry request", zap.Duration("delay", delay), zap.Error(err))
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+	}
+}
+
+// sign signs call with a fresh nonce and timestamp.
+func (m *Merchant) sign(call *Call) error {
+	nonce := m.nonce()
+	timestampMilli := fmt.Sprintf("%d", m.now().UnixMilli())
+	payload := BuildPayload(string(call.Body), timestampMilli, nonce)
 	signature, err := Sign(m.secret, []byte(payload))
 	if err != nil {
 		return fmt.Errorf("sign(): %w", err)
 	}
 
-	h := http.Header{}
+	if call.Header == nil {
+		call.Header = http.Header{}
+	}
+	h := call.Header
 	h.Set("BinancePay-Certificate-SN", m.apiKey)
 	h.Set("BinancePay-Nonce", nonce)
 	h.Set("BinancePay-Timestamp", timestampMilli)
 	h.Set("BinancePay-Signature", signature)
 	h.Set("Content-Type", "application/json;charset=utf-8")
+	call.signedBody = append([]byte(nil), call.Body...)
+	return nil
+}
 
-	method := "POST"
-	if httpMethodProvider, ok := req.(HttpMethodProvider); ok {
-		method = httpMethodProvider.HttpMethod()
+// send is the innermost invoker, it sends call and decodes the response into call.Response.
+// A call which was already sent is signed again, binance pay rejects reused nonces, so is a call whose
+// Body was changed by an interceptor.
+func (m *Merchant) send(ctx context.Context, call *Call) error {
+	if call.sent || !bytes.Equal(call.Body, call.signedBody) {
+		if err := m.sign(call); err != nil {
+			return err
+		}
+	}
+	call.sent = true
+	call.retryable = false
+	logger := call.logger
+	if logger == nil {
+		logger = m.logger
 	}
 
 	logger.Debug("new request",
-		zap.String("method", method),
-		zap.String("endpoint", req.EndPoint()),
-		zap.ByteString("body", body),
+		zap.String("method", call.Method),
+		zap.String("endpoint", call.Request.EndPoint()),
+		m.redactor.body("body", call.Body),
 		zap.Strings("header", []string{
-			m.apiKey,
-			nonce,
-			timestampMilli,
-			signature,
+			m.redactor.secret(call.Header.Get("BinancePay-Certificate-SN")),
+			call.Header.Get("BinancePay-Nonce"),
+			call.Header.Get("BinancePay-Timestamp"),
+			m.redactor.secret(call.Header.Get("BinancePay-Signature")),
 		}),
 	)
-	httpReq, err := http.NewRequest(method, m.host+req.EndPoint(), bytes.NewReader(body))
+	httpReq, err := http.NewRequestWithContext(ctx, call.Method, call.URL, bytes.NewReader(call.Body))
 	if err != nil {
-		return fmt.Errorf("http.NewRequest(): %w", err)
+		return fmt.Errorf("http.NewRequestWithContext(): %w", err)
 	}
 
-	httpReq.Header = h
+	httpReq.Header = call.Header.Clone()
 	resp, err := m.httpClient.Do(httpReq)
 	if err != nil {
+		call.retryable = ctx.Err() == nil
 		return fmt.Errorf("httpClient.Do(): %w", err)
 	}
 
@@ -248,19 +414,38 @@ func (m *Merchant) Do(req IRequest, response IResponse) (err error) {
 	}
 
 	defer resp.Body.Close()
-	respBytes, err := ioutil.ReadAll(resp.Body)
+	call.StatusCode = resp.StatusCode
+	call.ResponseBody, err = ioutil.ReadAll(resp.Body)
 	if err != nil {
+		call.retryable = ctx.Err() == nil
 		return fmt.Errorf("ioutil.ReadAlll(resp.Body): %w", err)
 	}
 
-	logger.Debug("got resp", zap.String("status", resp.Status), zap.ByteString("body", respBytes))
+	logger.Debug("got resp", zap.String("status", resp.Status), m.redactor.body("body", call.ResponseBody))
 
-	if err = json.Unmarshal(respBytes, response); err != nil {
+	nonce := call.Header.Get("BinancePay-Nonce")
+	if resp.StatusCode >= http.StatusInternalServerError || resp.StatusCode == http.StatusTooManyRequests {
+		call.retryable = true
+		return &APIError{
+			Message:    http.StatusText(resp.StatusCode),
+			HTTPStatus: resp.StatusCode,
+			Nonce:      nonce,
+			RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After")),
+		}
+	}
+
+	if err = json.Unmarshal(call.ResponseBody, call.Response); err != nil {
 		return fmt.Errorf("json.Unmarshal(respBytes): %w", err)
 	}
 
-	if !response.Success() {
-		return response.GetError()
+	if !call.Response.Success() {
+		err = call.Response.GetError()
+		var apiErr *APIError
+		if errors.As(err, &apiErr) {
+			apiErr.HTTPStatus = resp.StatusCode
+			apiErr.Nonce = nonce
+		}
+		return err
 	}
 
 	return nil
@@ -40,9 +40,8 @@ func (c fixedPublicKeyTestCache) GetJSON(ctx context.Context, key string, i inte
 		return false, nil
 	}
 
-	cert, _ := i.(*Certificate)
-	cert.CertSerial = "abc"
-	cert.CertPublic = c.publicKey
+	certs, _ := i.(*[]Certificate)
+	*certs = []Certificate{{CertSerial: "abc", CertPublic: c.publicKey}}
 	return true, nil
 }
 
@@ -133,7 +132,7 @@ FvXTzIoctZM1IvDrCwIDAQAB
 
 	rawReq, err := client.VerifyAndParseWebhookRequest(httpReq)
 	assert.Nil(t, err, err)
-	assert.Equal(t, "PAY_SUCCESS", rawReq.BizStatus)
+	assert.Equal(t, BizStatusPaySuccess, rawReq.BizStatus)
 
 	switch rawReq.BizType {
 	case NotiBizTypeOrder:
@@ -155,3 +154,45 @@ FvXTzIoctZM1IvDrCwIDAQAB
 		panic("should never reach")
 	}
 }
+
+func TestDoContextCanceled(t *testing.T) {
+	client := NewMerchant("", "", nil, logger)
+	client.httpClient = mockHttpClient(func(request *http.Request) (*http.Response, error) {
+		<-request.Context().Done()
+		return nil, request.Context().Err()
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	var resp Response[QueryOrderResult]
+	err := client.DoContext(ctx, &QueryOrderRequest{MerchantTradeNo: "9825382937292"}, &resp)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+type ctxKey struct{}
+
+type ctxRecordingTestCache struct {
+	fixedPublicKeyTestCache
+	values []interface{}
+}
+
+func (c *ctxRecordingTestCache) GetJSON(ctx context.Context, key string, i interface{}) (ok bool, err error) {
+	c.values = append(c.values, ctx.Value(ctxKey{}))
+	return c.fixedPublicKeyTestCache.GetJSON(ctx, key, i)
+}
+
+func TestWebhookContextPropagatesToCache(t *testing.T) {
+	cache := &ctxRecordingTestCache{fixedPublicKeyTestCache: fixedPublicKeyTestCache{exist: true, publicKey: testDataPublicKey}}
+	client := NewMerchant("", "", cache, logger)
+	body := `{"bizType":"PAY","bizId":"111","data":"testdata","bizStatus":"SUCCESS"}`
+	httpReq, err := http.NewRequest("POST", "/", strings.NewReader(body))
+	assert.Nil(t, err, err)
+	httpReq.Header.Set("BinancePay-Nonce", "abc")
+	httpReq.Header.Set("BinancePay-Timestamp", "1654943252000")
+	httpReq.Header.Set("BinancePay-Signature", generateSignature([]byte(BuildPayload(body, "1654943252000", "abc"))))
+
+	ctx := context.WithValue(context.Background(), ctxKey{}, "trace")
+	_, err = client.VerifyAndParseWebhookRequestContext(ctx, httpReq)
+	assert.Nil(t, err, err)
+	assert.Equal(t, []interface{}{"trace"}, cache.values)
+}
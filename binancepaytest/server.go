@@ -0,0 +1,423 @@
+// Package binancepaytest provides an in-memory emulator of the binance pay API for integration tests.
+//
+//	srv := binancepaytest.NewServer("api-key", "secret")
+//	defer srv.Close()
+//	merchant := binancepay.NewMerchant("api-key", "secret", binancepay.NewMemoryCache(0), logger,
+//		binancepay.WithHost(srv.URL), binancepay.WithHTTPClient(srv.Client()))
+package binancepaytest
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"fmt"
+	"github.com/dmitrorezn/go-binancepay"
+	"github.com/shopspring/decimal"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// DefaultOrderExpireTime is the expire time of orders created without orderExpireTime.
+const DefaultOrderExpireTime = time.Hour
+
+// Order is the state of an order created on the emulator.
+type Order struct {
+	PrepayId        string
+	TransactionId   string
+	MerchantTradeNo string
+	Status          binancepay.OrderStatus
+	Currency        string
+	OrderAmount     decimal.Decimal
+	TerminalType    string
+	CreateTime      time.Time
+	ExpireTime      time.Time
+	TransactTime    time.Time
+	Goods           binancepay.Goods // the first of the GoodsDetails when the order was created without Goods
+}
+
+type Option func(s *Server)
+
+// WithWebhookURL sets the url receiving the webhooks fired by the emulator.
+func WithWebhookURL(url string) Option {
+	return func(s *Server) {
+		s.webhookURL = url
+	}
+}
+
+// WithClock overrides time.Now used for order and webhook timestamps.
+func WithClock(now func() time.Time) Option {
+	return func(s *Server) {
+		s.now = now
+	}
+}
+
+// WithWebhookClient overrides http.DefaultClient used to deliver webhooks.
+func WithWebhookClient(client *http.Client) Option {
+	return func(s *Server) {
+		s.webhookClient = client
+	}
+}
+
+// Server emulates the order and certificate endpoints of the binance pay API.
+// Requests are authenticated with the configured api key and secret, orders are kept in memory.
+type Server struct {
+	*httptest.Server
+
+	apiKey string
+	secret []byte
+
+	signer        *binancepay.WebhookSigner
+	certificate   binancepay.Certificate
+	webhookURL    string
+	webhookClient *http.Client
+	now           func() time.Time
+
+	mu            sync.Mutex
+	orders        map[string]*Order // by merchantTradeNo
+	prepayIds     map[string]string // prepayId to merchantTradeNo
+	lastId        int64
+	webhookErrors []error
+	webhooks      sync.WaitGroup
+}
+
+// NewServer starts an emulator accepting requests signed with apiKey and secret.
+// The caller should call Close when finished, to shut it down.
+func NewServer(apiKey, secret string, opts ...Option) *Server {
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		panic(fmt.Sprintf("binancepaytest: rsa.GenerateKey(): %v", err))
+	}
+
+	s := &Server{
+		apiKey:        apiKey,
+		secret:        []byte(secret),
+		webhookClient: http.DefaultClient,
+		now:           time.Now,
+		orders:        map[string]*Order{},
+		prepayIds:     map[string]string{},
+		lastId:        1000000000,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	s.signer = binancepay.NewWebhookSigner(privateKey, binancepay.Nonce(), binancepay.WithSignerClock(s.now))
+	if s.certificate, err = s.signer.Certificate(); err != nil {
+		panic(fmt.Sprintf("binancepaytest: %v", err))
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/binancepay/openapi/certificates", s.authenticated(s.handleCertificates))
+	mux.HandleFunc("/binancepay/openapi/v2/order", s.authenticated(s.handleCreateOrder))
+	mux.HandleFunc("/binancepay/openapi/v2/order/query", s.authenticated(s.handleQueryOrder))
+	mux.HandleFunc("/binancepay/openapi/order/close", s.authenticated(s.handleCloseOrder))
+	s.Server = httptest.NewServer(mux)
+	return s
+}
+
+// Close waits for the webhooks fired in the background and shuts the emulator down.
+func (s *Server) Close() {
+	s.webhooks.Wait()
+	s.Server.Close()
+}
+
+// Certificate returns the certificate whose key signs the webhooks of the emulator.
+func (s *Server) Certificate() binancepay.Certificate {
+	return s.certificate
+}
+
+// Order returns a snapshot of the order with the given merchantTradeNo.
+func (s *Server) Order(merchantTradeNo string) (Order, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	order, ok := s.orders[merchantTradeNo]
+	if !ok {
+		return Order{}, false
+	}
+	s.expire(order)
+	return *order, true
+}
+
+// WebhookErrors returns the errors of webhooks fired in the background by the emulator.
+func (s *Server) WebhookErrors() []error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]error(nil), s.webhookErrors...)
+}
+
+// Pay marks the order as paid and fires a PAY_SUCCESS webhook, as if the buyer paid it.
+func (s *Server) Pay(merchantTradeNo string) error {
+	s.mu.Lock()
+	order, ok := s.orders[merchantTradeNo]
+	if !ok {
+		s.mu.Unlock()
+		return fmt.Errorf("order %s not found", merchantTradeNo)
+	}
+	s.expire(order)
+	if order.Status != binancepay.OrderStatusInitial {
+		s.mu.Unlock()
+		return fmt.Errorf("order %s is %s", merchantTradeNo, order.Status)
+	}
+	order.Status = binancepay.OrderStatusPaid
+	order.TransactTime = s.now()
+	order.TransactionId = "M_P_" + strconv.FormatInt(s.nextId(), 10)
+	noti := orderNoti(order)
+	s.mu.Unlock()
+
+	return s.FireWebhook(binancepay.NotiBizTypeOrder, order.PrepayId, binancepay.BizStatusPaySuccess, noti)
+}
+
+// FireWebhook delivers a webhook signed with the key of Certificate, see binancepay.WebhookSigner, to the configured webhook url.
+// An error is returned when the delivery fails or the receiver does not acknowledge it with SUCCESS.
+func (s *Server) FireWebhook(bizType binancepay.NotiBizType, bizId string, bizStatus binancepay.BizStatus, data interface{}) error {
+	if s.webhookURL == "" {
+		return fmt.Errorf("webhook url is not configured")
+	}
+
+	req, err := s.signer.NewRequest(s.webhookURL, bizType, bizId, bizStatus, data)
+	if err != nil {
+		return err
+	}
+
+	resp, err := s.webhookClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("webhookClient.Do(): %w", err)
+	}
+	defer resp.Body.Close()
+
+	var ack struct {
+		ReturnCode    string  `json:"returnCode"`
+		ReturnMessage *string `json:"returnMessage"`
+	}
+	respBody, _ := ioutil.ReadAll(resp.Body)
+	if err = json.Unmarshal(respBody, &ack); err != nil || ack.ReturnCode != "SUCCESS" {
+		return fmt.Errorf("webhook not acknowledged: status=%s body=%s", resp.Status, respBody)
+	}
+	return nil
+}
+
+func (s *Server) nextId() int64 {
+	s.lastId++
+	return s.lastId
+}
+
+// expire moves an unpaid order past its expire time to binancepay.OrderStatusExpired, s.mu must be held.
+func (s *Server) expire(order *Order) {
+	if order.Status == binancepay.OrderStatusInitial && !s.now().Before(order.ExpireTime) {
+		order.Status = binancepay.OrderStatusExpired
+	}
+}
+
+func orderNoti(order *Order) binancepay.OrderNoti {
+	return binancepay.OrderNoti{
+		MerchantTradeNo: order.MerchantTradeNo,
+		TotalFee:        order.OrderAmount,
+		TransactTime:    order.TransactTime.UnixMilli(),
+		Currency:        order.Currency,
+		ProductType:     order.Goods.GoodsCategory,
+		ProductName:     order.Goods.GoodsName,
+		TradeType:       order.TerminalType,
+		TransactionId:   order.TransactionId,
+	}
+}
+
+type apiResponse struct {
+	Status string      `json:"status"`
+	Code   string      `json:"code"`
+	Data   interface{} `json:"data"`
+	ErrMsg string      `json:"errorMessage,omitempty"`
+}
+
+func writeResponse(w http.ResponseWriter, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(apiResponse{Status: "SUCCESS", Code: binancepay.CodeSuccess, Data: data})
+}
+
+func writeError(w http.ResponseWriter, httpStatus int, apiErr *binancepay.APIError) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(httpStatus)
+	_ = json.NewEncoder(w).Encode(apiResponse{Status: "FAIL", Code: apiErr.Code, ErrMsg: apiErr.Message})
+}
+
+// authenticated checks the api key and the HMAC signature of requests before passing the body to next.
+func (s *Server) authenticated(next func(w http.ResponseWriter, body []byte)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			writeError(w, http.StatusMethodNotAllowed, binancepay.ErrInvalidRequest)
+			return
+		}
+		body, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, binancepay.ErrInvalidRequest)
+			return
+		}
+		if r.Header.Get("BinancePay-Certificate-SN") != s.apiKey {
+			writeError(w, http.StatusBadRequest, binancepay.ErrInvalidApiKeyOrIp)
+			return
+		}
+
+		payload := binancepay.BuildPayload(string(body), r.Header.Get("BinancePay-Timestamp"), r.Header.Get("BinancePay-Nonce"))
+		expected, err := binancepay.Sign(s.secret, []byte(payload))
+		if err != nil || expected != r.Header.Get("BinancePay-Signature") {
+			writeError(w, http.StatusBadRequest, binancepay.ErrInvalidSignature)
+			return
+		}
+
+		next(w, body)
+	}
+}
+
+func (s *Server) handleCertificates(w http.ResponseWriter, body []byte) {
+	writeResponse(w, binancepay.QueryCertificateResult{s.certificate})
+}
+
+func (s *Server) handleCreateOrder(w http.ResponseWriter, body []byte) {
+	var req binancepay.CreateOrderV2Request
+	if err := json.Unmarshal(body, &req); err != nil {
+		writeError(w, http.StatusBadRequest, binancepay.ErrInvalidRequest)
+		return
+	}
+	if err := req.ValidateAt(s.now()); err != nil {
+		writeError(w, http.StatusBadRequest, binancepay.ErrMandatoryParamEmptyOrMalformed)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, exists := s.orders[req.MerchantTradeNo]; exists {
+		writeError(w, http.StatusOK, binancepay.ErrMerchantTradeNoDuplicated)
+		return
+	}
+
+	now := s.now()
+	expireTime := now.Add(DefaultOrderExpireTime)
+	if req.OrderExpireTime > 0 {
+		expireTime = time.UnixMilli(req.OrderExpireTime)
+	}
+	order := &Order{
+		PrepayId:        strconv.FormatInt(s.nextId(), 10),
+		MerchantTradeNo: req.MerchantTradeNo,
+		Status:          binancepay.OrderStatusInitial,
+		Currency:        req.Currency,
+		OrderAmount:     req.OrderAmount,
+		TerminalType:    req.Env.TerminalType,
+		CreateTime:      now,
+		ExpireTime:      expireTime,
+		Goods:           req.Goods,
+	}
+	if order.Goods == (binancepay.Goods{}) && len(req.GoodsDetails) > 0 {
+		order.Goods = req.GoodsDetails[0]
+	}
+	s.orders[order.MerchantTradeNo] = order
+	s.prepayIds[order.PrepayId] = order.MerchantTradeNo
+
+	writeResponse(w, s.checkout(order))
+}
+
+// checkout returns the checkout data of order.
+func (s *Server) checkout(order *Order) binancepay.CreateOrderV2Result {
+	checkoutUrl := s.URL + "/checkout/" + order.PrepayId
+	return binancepay.CreateOrderV2Result{
+		PrepayId:     order.PrepayId,
+		TerminalType: order.TerminalType,
+		ExpireTime:   order.ExpireTime.UnixMilli(),
+		QrcodeLink:   checkoutUrl + "/qrcode.png",
+		QrContent:    checkoutUrl,
+		CheckoutUrl:  checkoutUrl,
+		Deeplink:     "bnc://app.binance.com/payment/secpay?tempToken=" + order.PrepayId,
+		UniversalUrl: checkoutUrl + "?universal=1",
+	}
+}
+
+// lookup finds the order referenced by prepayId or merchantTradeNo, s.mu must be held.
+func (s *Server) lookup(prepayId, merchantTradeNo string) (*Order, bool) {
+	if prepayId != "" {
+		merchantTradeNo = s.prepayIds[prepayId]
+	}
+	order, ok := s.orders[merchantTradeNo]
+	return order, ok
+}
+
+func (s *Server) handleQueryOrder(w http.ResponseWriter, body []byte) {
+	var req binancepay.QueryOrderRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		writeError(w, http.StatusBadRequest, binancepay.ErrInvalidRequest)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	order, ok := s.lookup(req.PrepayId, req.MerchantTradeNo)
+	if !ok {
+		writeError(w, http.StatusOK, binancepay.ErrOrderNotFound)
+		return
+	}
+	s.expire(order)
+
+	result := binancepay.QueryOrderResult{
+		PrepayId:        order.PrepayId,
+		TransactionId:   order.TransactionId,
+		MerchantTradeNo: order.MerchantTradeNo,
+		Status:          order.Status,
+		Currency:        order.Currency,
+		OrderAmount:     order.OrderAmount.String(),
+		CreateTime:      order.CreateTime.UnixMilli(),
+	}
+	if !order.TransactTime.IsZero() {
+		result.TransactTime = order.TransactTime.UnixMilli()
+	}
+	if order.Status.Pending() {
+		checkout := s.checkout(order)
+		result.QrcodeLink = checkout.QrcodeLink
+		result.QrContent = checkout.QrContent
+		result.CheckoutUrl = checkout.CheckoutUrl
+		result.Deeplink = checkout.Deeplink
+		result.UniversalUrl = checkout.UniversalUrl
+	}
+	writeResponse(w, result)
+}
+
+func (s *Server) handleCloseOrder(w http.ResponseWriter, body []byte) {
+	var req binancepay.CloseOrderRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		writeError(w, http.StatusBadRequest, binancepay.ErrInvalidRequest)
+		return
+	}
+
+	s.mu.Lock()
+	order, ok := s.lookup(req.PrepayId, req.MerchantTradeNo)
+	if !ok {
+		s.mu.Unlock()
+		writeError(w, http.StatusOK, binancepay.ErrOrderNotFound)
+		return
+	}
+	s.expire(order)
+	if order.Status != binancepay.OrderStatusInitial {
+		s.mu.Unlock()
+		writeError(w, http.StatusOK, binancepay.ErrInvalidParamWrongValue)
+		return
+	}
+	order.Status = binancepay.OrderStatusCanceled
+	noti := orderNoti(order)
+	s.mu.Unlock()
+
+	// like binance pay, the close result is notified asynchronously. The webhook is added before responding,
+	// so a Close following the response waits for it.
+	if s.webhookURL != "" {
+		s.webhooks.Add(1)
+		go func() {
+			defer s.webhooks.Done()
+			if err := s.FireWebhook(binancepay.NotiBizTypeOrder, order.PrepayId, binancepay.BizStatusPayClosed, noti); err != nil {
+				s.mu.Lock()
+				s.webhookErrors = append(s.webhookErrors, err)
+				s.mu.Unlock()
+			}
+		}()
+	}
+
+	writeResponse(w, true)
+}
@@ -0,0 +1,168 @@
+package binancepaytest_test
+
+import (
+	"context"
+	"github.com/dmitrorezn/go-binancepay"
+	"github.com/dmitrorezn/go-binancepay/binancepaytest"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+var logger, _ = zap.NewDevelopment()
+
+func newOrderRequest(merchantTradeNo string) *binancepay.CreateOrderV2Request {
+	return &binancepay.CreateOrderV2Request{
+		Env: binancepay.Env{
+			TerminalType: "WEB",
+		},
+		MerchantTradeNo: merchantTradeNo,
+		Currency:        "USDT",
+		OrderAmount:     decimal.RequireFromString("12.5"),
+		Goods: binancepay.Goods{
+			GoodsType:        "02",
+			GoodsCategory:    "6000",
+			ReferenceGoodsId: "sku-1",
+			GoodsName:        "game credits",
+		},
+	}
+}
+
+func TestPaymentFlow(t *testing.T) {
+	var mu sync.Mutex
+	var notis []string
+
+	var merchant *binancepay.Merchant
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		binancepay.NewWebhookHandler(merchant).
+			OnOrder(func(ctx context.Context, noti *binancepay.OrderNoti, bizStatus binancepay.BizStatus) error {
+				mu.Lock()
+				defer mu.Unlock()
+				notis = append(notis, noti.MerchantTradeNo+":"+string(bizStatus))
+				return nil
+			}).
+			ServeHTTP(w, r)
+	}))
+	defer receiver.Close()
+
+	srv := binancepaytest.NewServer("api-key", "secret", binancepaytest.WithWebhookURL(receiver.URL))
+	defer srv.Close()
+
+	merchant = binancepay.NewMerchant("api-key", "secret", binancepay.NewMemoryCache(0), logger,
+		binancepay.WithHost(srv.URL),
+		binancepay.WithHTTPClient(srv.Client()),
+	)
+
+	var created binancepay.Response[binancepay.CreateOrderV2Result]
+	err := merchant.Do(newOrderRequest("order1"), &created)
+	assert.Nil(t, err, err)
+	assert.NotEmpty(t, created.Data.PrepayId)
+	assert.NotEmpty(t, created.Data.CheckoutUrl)
+
+	err = merchant.Do(newOrderRequest("order1"), &created)
+	assert.True(t, binancepay.IsDuplicateOrder(err), err)
+
+	err = srv.Pay("order1")
+	assert.Nil(t, err, err)
+
+	var queried binancepay.Response[binancepay.QueryOrderResult]
+	err = merchant.Do(&binancepay.QueryOrderRequest{PrepayId: created.Data.PrepayId}, &queried)
+	assert.Nil(t, err, err)
+	assert.Equal(t, binancepay.OrderStatusPaid, queried.Data.Status)
+	assert.Equal(t, "12.5", queried.Data.OrderAmount)
+
+	err = merchant.Do(newOrderRequest("order2"), &created)
+	assert.Nil(t, err, err)
+	var closed binancepay.Response[binancepay.CloseOrderResult]
+	err = merchant.Do(&binancepay.CloseOrderRequest{MerchantTradeNo: "order2"}, &closed)
+	assert.Nil(t, err, err)
+	assert.Equal(t, binancepay.CloseOrderResult(true), closed.Data)
+
+	err = merchant.Do(&binancepay.QueryOrderRequest{MerchantTradeNo: "missing"}, &queried)
+	assert.True(t, binancepay.IsNotFound(err), err)
+
+	srv.Close()
+	assert.Empty(t, srv.WebhookErrors())
+	assert.ElementsMatch(t, []string{"order1:PAY_SUCCESS", "order2:PAY_CLOSED"}, notis)
+}
+
+func TestRejectsInvalidSignature(t *testing.T) {
+	srv := binancepaytest.NewServer("api-key", "secret")
+	defer srv.Close()
+
+	merchant := binancepay.NewMerchant("api-key", "wrong secret", nil, logger,
+		binancepay.WithHost(srv.URL),
+		binancepay.WithHTTPClient(srv.Client()),
+	)
+	var resp binancepay.Response[binancepay.QueryCertificateResult]
+	err := merchant.Do(&binancepay.QueryCertificateRequest{}, &resp)
+	assert.True(t, binancepay.IsSignatureError(err), err)
+}
+
+func TestOrderExpires(t *testing.T) {
+	now := time.Now()
+	srv := binancepaytest.NewServer("api-key", "secret", binancepaytest.WithClock(func() time.Time { return now }))
+	defer srv.Close()
+
+	merchant := binancepay.NewMerchant("api-key", "secret", nil, logger,
+		binancepay.WithHost(srv.URL),
+		binancepay.WithHTTPClient(srv.Client()),
+	)
+	var created binancepay.Response[binancepay.CreateOrderV2Result]
+	err := merchant.Do(newOrderRequest("order1"), &created)
+	assert.Nil(t, err, err)
+
+	now = now.Add(binancepaytest.DefaultOrderExpireTime)
+	order, ok := srv.Order("order1")
+	assert.True(t, ok)
+	assert.Equal(t, binancepay.OrderStatusExpired, order.Status)
+	assert.NotNil(t, srv.Pay("order1"))
+}
+
+func TestCreateOrderValidatedAtServerClock(t *testing.T) {
+	now := time.UnixMilli(1655859345000)
+	clock := func() time.Time { return now }
+	srv := binancepaytest.NewServer("api-key", "secret", binancepaytest.WithClock(clock))
+	defer srv.Close()
+
+	merchant := binancepay.NewMerchant("api-key", "secret", nil, logger,
+		binancepay.WithHost(srv.URL),
+		binancepay.WithHTTPClient(srv.Client()),
+		binancepay.WithClock(clock),
+	)
+	// in the future of the emulator, in the past of the wall clock
+	req := newOrderRequest("order1")
+	req.OrderExpireTime = now.Add(time.Hour).UnixMilli()
+	var created binancepay.Response[binancepay.CreateOrderV2Result]
+	err := merchant.Do(req, &created)
+	assert.Nil(t, err, err)
+}
+
+func TestCreateOrderIdempotent(t *testing.T) {
+	srv := binancepaytest.NewServer("api-key", "secret")
+	defer srv.Close()
+
+	merchant := binancepay.NewMerchant("api-key", "secret", nil, logger,
+		binancepay.WithHost(srv.URL),
+		binancepay.WithHTTPClient(srv.Client()),
+	)
+
+	created, err := merchant.CreateOrderIdempotent(context.Background(), newOrderRequest("order1"))
+	assert.Nil(t, err, err)
+
+	// the duplicate merchantTradeNo falls back to the existing order
+	again, err := merchant.CreateOrderIdempotent(context.Background(), newOrderRequest("order1"))
+	assert.Nil(t, err, err)
+	assert.Equal(t, created.PrepayId, again.PrepayId)
+	assert.Equal(t, created.CheckoutUrl, again.CheckoutUrl)
+
+	conflicting := newOrderRequest("order1")
+	conflicting.OrderAmount = decimal.RequireFromString("13")
+	_, err = merchant.CreateOrderIdempotent(context.Background(), conflicting)
+	assert.ErrorIs(t, err, binancepay.ErrOrderConflict)
+}
@@ -0,0 +1,222 @@
+package binancepay
+
+import (
+	"context"
+	"crypto/rsa"
+	"errors"
+	"fmt"
+	"go.uber.org/zap"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultCertRefreshInterval is the minimum interval between two certificate fetches
+	// triggered by unknown serials or failed verifications.
+	DefaultCertRefreshInterval = time.Minute
+
+	certCacheTTL = 24 * time.Hour
+)
+
+// certKeys holds the public keys of the binance pay certificates keyed by serial.
+type certKeys struct {
+	mu        sync.RWMutex
+	keys      map[string]*rsa.PublicKey // nil until loaded
+	fetchedAt time.Time                 // last fetch from the certificates API
+}
+
+func (m *Merchant) certCacheKey() string {
+	return "binance-pay:certs:" + m.apiKey
+}
+
+// webhookPublicKeys returns the keys to verify a webhook signed by the certificate with the given serial,
+// all keys are returned when serial is empty. Certificates are loaded on first use and refetched
+// when serial is unknown.
+func (m *Merchant) webhookPublicKeys(ctx context.Context, serial string) ([]*rsa.PublicKey, error) {
+	keys, loaded := m.candidatePublicKeys(serial)
+	if !loaded {
+		if err := m.loadCertificates(ctx); err != nil {
+			return nil, &webhookUnavailableError{err: err}
+		}
+		keys, _ = m.candidatePublicKeys(serial)
+	}
+	if len(keys) == 0 {
+		m.logger.Info("unknown binance cert serial", zap.String("serial", serial))
+		if _, err := m.refreshCertificates(ctx); err != nil {
+			return nil, &webhookUnavailableError{err: err}
+		}
+		keys, _ = m.candidatePublicKeys(serial)
+	}
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("unknown certificate serial %q", serial)
+	}
+	return keys, nil
+}
+
+func (m *Merchant) candidatePublicKeys(serial string) (keys []*rsa.PublicKey, loaded bool) {
+	m.certs.mu.RLock()
+	defer m.certs.mu.RUnlock()
+	if m.certs.keys == nil {
+		return nil, false
+	}
+	if serial != "" {
+		if key, ok := m.certs.keys[serial]; ok {
+			return []*rsa.PublicKey{key}, true
+		}
+		return nil, true
+	}
+	for _, key := range m.certs.keys {
+		keys = append(keys, key)
+	}
+	return keys, true
+}
+
+// shareCertFlight runs fn once for all concurrent callers using the same key,
+// every caller still returns as soon as its own ctx is done. fn runs without the cancellation of ctx,
+// so a canceled caller doesn't fail the others.
+func (m *Merchant) shareCertFlight(ctx context.Context, key string, fn func(ctx context.Context) (interface{}, error)) (interface{}, error) {
+	flightCtx := context.WithoutCancel(ctx)
+	select {
+	case res := <-m.certFlight.DoChan(key, func() (interface{}, error) { return fn(flightCtx) }):
+		return res.Val, res.Err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// loadCertificates loads the certificates from the cache, falling back to the certificates API.
+// Concurrent calls share a single load.
+func (m *Merchant) loadCertificates(ctx context.Context) error {
+	_, err := m.shareCertFlight(ctx, "load", func(ctx context.Context) (interface{}, error) {
+		if _, loaded := m.candidatePublicKeys(""); loaded {
+			return nil, nil
+		}
+		return nil, m.doLoadCertificates(ctx)
+	})
+	return err
+}
+
+func (m *Merchant) doLoadCertificates(ctx context.Context) error {
+	m.logger.Debug("load binance certs")
+
+	var certs []Certificate
+	if m.cache != nil {
+		exists, err := m.cache.GetJSON(ctx, m.certCacheKey(), &certs)
+		if err != nil {
+			m.logger.Error("failed to get binance certs from cache", zap.Error(err))
+			return fmt.Errorf("queryCertificatesFromCache(): %w", err)
+		}
+		if exists && len(certs) > 0 {
+			return m.setCertificates(certs, false)
+		}
+	}
+
+	certs, err := m.fetchCertificates(ctx)
+	if err != nil {
+		return err
+	}
+	return m.setCertificates(certs, true)
+}
+
+// refreshCertificates refetches the certificates from the certificates API,
+// at most once per certRefreshInterval. refreshed is false when the fetch was skipped.
+// Concurrent calls share a single fetch.
+func (m *Merchant) refreshCertificates(ctx context.Context) (refreshed bool, err error) {
+	res, err := m.shareCertFlight(ctx, "refresh", func(ctx context.Context) (interface{}, error) {
+		return m.doRefreshCertificates(ctx)
+	})
+	if err != nil {
+		return false, err
+	}
+	return res.(bool), nil
+}
+
+func (m *Merchant) doRefreshCertificates(ctx context.Context) (refreshed bool, err error) {
+	m.certs.mu.RLock()
+	fetchedAt := m.certs.fetchedAt
+	m.certs.mu.RUnlock()
+	if !fetchedAt.IsZero() && m.now().Sub(fetchedAt) < m.certRefreshInterval {
+		return false, nil
+	}
+
+	m.logger.Info("refresh binance certs")
+	certs, err := m.fetchCertificates(ctx)
+	if err != nil {
+		return false, err
+	}
+	if err = m.setCertificates(certs, true); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (m *Merchant) fetchCertificates(ctx context.Context) (_ []Certificate, err error) {
+	ctx, span := m.telemetry.tracer.Start(ctx, SpanFetchCertificates)
+	defer func() { endSpan(span, err) }()
+
+	req := &QueryCertificateRequest{}
+	var resp Response[QueryCertificateResult]
+	if err := m.DoContext(ctx, req, &resp); err != nil {
+		m.logger.Error("failed to get binance certs from query certificates API", zap.Error(err))
+		return nil, fmt.Errorf("queryCertificates(): %w", err)
+	}
+	if len(resp.Data) == 0 {
+		return nil, fmt.Errorf("got empty certificates response")
+	}
+	span.SetAttributes(AttrCertificates.Int(len(resp.Data)))
+
+	if m.cache != nil {
+		if err := m.cache.SetJSON(ctx, m.certCacheKey(), resp.Data, certCacheTTL); err != nil {
+			return nil, fmt.Errorf("cacheCertificates(): %w", err)
+		}
+	}
+	return resp.Data, nil
+}
+
+func (m *Merchant) setCertificates(certs []Certificate, fetched bool) error {
+	keys := make(map[string]*rsa.PublicKey, len(certs))
+	for _, cert := range certs {
+		pub, err := ParsePublicKey(cert.CertPublic)
+		if err != nil {
+			return fmt.Errorf("ParsePublicKey(%s): %w", cert.CertSerial, err)
+		}
+		keys[cert.CertSerial] = pub
+	}
+
+	m.certs.mu.Lock()
+	defer m.certs.mu.Unlock()
+	m.certs.keys = keys
+	if fetched {
+		m.certs.fetchedAt = m.now()
+	}
+	return nil
+}
+
+// preloadCertificates installs the certificates given with WithCertificates.
+func (m *Merchant) preloadCertificates() {
+	keys := make(map[string]*rsa.PublicKey, len(m.preloadedCerts))
+	for _, cert := range m.preloadedCerts {
+		pub, err := ParsePublicKey(cert.CertPublic)
+		if err != nil {
+			m.logger.Error("failed to parse preloaded binance cert", zap.String("serial", cert.CertSerial), zap.Error(err))
+			continue
+		}
+		keys[cert.CertSerial] = pub
+	}
+	m.certs.keys = keys
+}
+
+// errNoCertificate is returned when there is no key to verify a webhook with.
+var errNoCertificate = errors.New("no certificate to verify the signature")
+
+func verifySignatureWithAny(keys []*rsa.PublicKey, payload, signature []byte) (err error) {
+	if len(keys) == 0 {
+		return errNoCertificate
+	}
+	for _, key := range keys {
+		if err = verifySignature(key, payload, signature); err == nil {
+			return nil
+		}
+	}
+	return err
+}
@@ -0,0 +1,195 @@
+package binancepay
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func newTestCertificate(t *testing.T, serial string) (*rsa.PrivateKey, Certificate) {
+	priv, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.Nil(t, err, err)
+	cert, err := NewWebhookSigner(priv, serial).Certificate()
+	assert.Nil(t, err, err)
+	return priv, cert
+}
+
+func newWebhookRequestSignedBy(t *testing.T, priv *rsa.PrivateKey, serial, body string) *http.Request {
+	timestamp := "1654943252000"
+	nonce := "abc"
+	sig, err := NewWebhookSigner(priv, serial).Sign([]byte(BuildPayload(body, timestamp, nonce)))
+	assert.Nil(t, err, err)
+
+	httpReq, err := http.NewRequest("POST", "/webhook", strings.NewReader(body))
+	assert.Nil(t, err, err)
+	httpReq.Header.Set("BinancePay-Certificate-SN", serial)
+	httpReq.Header.Set("BinancePay-Nonce", nonce)
+	httpReq.Header.Set("BinancePay-Timestamp", timestamp)
+	httpReq.Header.Set("BinancePay-Signature", sig)
+	return httpReq
+}
+
+func TestWebhookCertificateRotation(t *testing.T) {
+	oldPriv, oldCert := newTestCertificate(t, "old")
+	newPriv, newCert := newTestCertificate(t, "new")
+	_, newerCert := newTestCertificate(t, "newer")
+
+	now := time.Now()
+	cache := NewMemoryCache(0)
+	client := NewMerchant("", "", cache, logger, WithClock(func() time.Time { return now }))
+
+	fetches := 0
+	certs := []Certificate{oldCert}
+	client.httpClient = certificatesHttpClient(t, &fetches, &certs)
+
+	_, err := client.VerifyAndParseWebhookRequest(newWebhookRequestSignedBy(t, oldPriv, "old", testOrderWebhookBody))
+	assert.Nil(t, err, err)
+	assert.Equal(t, 1, fetches)
+
+	var cached []Certificate
+	ok, _ := cache.GetJSON(context.Background(), client.certCacheKey(), &cached)
+	assert.True(t, ok)
+	assert.Equal(t, []Certificate{oldCert}, cached)
+
+	// binance rotates its key, the unknown serial is fetched once the refresh interval passed
+	certs = []Certificate{oldCert, newCert}
+	now = now.Add(DefaultCertRefreshInterval)
+	_, err = client.VerifyAndParseWebhookRequest(newWebhookRequestSignedBy(t, newPriv, "new", testOrderWebhookBody))
+	assert.Nil(t, err, err)
+	assert.Equal(t, 2, fetches)
+
+	// both keys are kept
+	_, err = client.VerifyAndParseWebhookRequest(newWebhookRequestSignedBy(t, oldPriv, "old", testOrderWebhookBody))
+	assert.Nil(t, err, err)
+	_, err = client.VerifyAndParseWebhookRequest(newWebhookRequestSignedBy(t, newPriv, "", testOrderWebhookBody))
+	assert.Nil(t, err, err)
+	assert.Equal(t, 2, fetches)
+
+	// refetches are rate limited
+	certs = []Certificate{oldCert, newCert, newerCert}
+	_, err = client.VerifyAndParseWebhookRequest(newWebhookRequestSignedBy(t, newPriv, "newer", testOrderWebhookBody))
+	assert.NotNil(t, err)
+	assert.Equal(t, 2, fetches)
+}
+
+func TestWebhookRefetchOnVerificationFailure(t *testing.T) {
+	_, staleCert := newTestCertificate(t, "abc")
+	priv, cert := newTestCertificate(t, "abc")
+
+	now := time.Now()
+	cache := NewMemoryCache(0)
+	_ = cache.SetJSON(context.Background(), "binance-pay:certs:", []Certificate{staleCert}, time.Hour)
+	client := NewMerchant("", "", cache, logger, WithClock(func() time.Time { return now }))
+
+	fetches := 0
+	certs := []Certificate{cert}
+	client.httpClient = certificatesHttpClient(t, &fetches, &certs)
+
+	_, err := client.VerifyAndParseWebhookRequest(newWebhookRequestSignedBy(t, priv, "abc", testOrderWebhookBody))
+	assert.Nil(t, err, err)
+	assert.Equal(t, 1, fetches)
+}
+
+func TestWebhookRejectedWhenSerialRotatedOut(t *testing.T) {
+	_, oldCert := newTestCertificate(t, "old")
+	_, newCert := newTestCertificate(t, "new")
+	attackerPriv, _ := newTestCertificate(t, "old")
+
+	client := NewMerchant("", "", nil, logger, WithCertificates(oldCert))
+
+	fetches := 0
+	certs := []Certificate{newCert}
+	client.httpClient = certificatesHttpClient(t, &fetches, &certs)
+
+	// the failed verification refetches the certificates, which no longer contain the serial
+	_, err := client.VerifyAndParseWebhookRequest(newWebhookRequestSignedBy(t, attackerPriv, "old", testOrderWebhookBody))
+	assert.NotNil(t, err)
+	assert.Equal(t, 1, fetches)
+}
+
+func TestConcurrentWebhooksShareCertificateFetch(t *testing.T) {
+	priv, cert := newTestCertificate(t, "abc")
+	client := NewMerchant("", "", NewMemoryCache(0), logger)
+
+	var fetches int32
+	client.httpClient = mockHttpClient(func(request *http.Request) (*http.Response, error) {
+		atomic.AddInt32(&fetches, 1)
+		time.Sleep(50 * time.Millisecond)
+		respBody, _ := json.Marshal(Response[QueryCertificateResult]{Status: "SUCCESS", Code: "000000", Data: []Certificate{cert}})
+		return &http.Response{
+			Body: ioutil.NopCloser(bytes.NewReader(respBody)),
+		}, nil
+	})
+
+	const n = 50
+	reqs := make([]*http.Request, n)
+	for i := range reqs {
+		reqs[i] = newWebhookRequestSignedBy(t, priv, "abc", testOrderWebhookBody)
+	}
+
+	var wg sync.WaitGroup
+	errs := make([]error, n)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, errs[i] = client.VerifyAndParseWebhookRequest(reqs[i])
+		}(i)
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		assert.Nil(t, err, err)
+	}
+	assert.Equal(t, int32(1), atomic.LoadInt32(&fetches))
+}
+
+func TestCanceledWebhookDoesNotFailSharedCertificateFetch(t *testing.T) {
+	priv, cert := newTestCertificate(t, "abc")
+	client := NewMerchant("", "", NewMemoryCache(0), logger)
+
+	started := make(chan struct{})
+	var once sync.Once
+	client.httpClient = mockHttpClient(func(request *http.Request) (*http.Response, error) {
+		once.Do(func() { close(started) })
+		select {
+		case <-request.Context().Done():
+			return nil, request.Context().Err()
+		case <-time.After(100 * time.Millisecond):
+		}
+		respBody, _ := json.Marshal(Response[QueryCertificateResult]{Status: "SUCCESS", Code: "000000", Data: []Certificate{cert}})
+		return &http.Response{
+			Body: ioutil.NopCloser(bytes.NewReader(respBody)),
+		}, nil
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	canceled := make(chan error, 1)
+	go func() {
+		_, err := client.VerifyAndParseWebhookRequestContext(ctx, newWebhookRequestSignedBy(t, priv, "abc", testOrderWebhookBody))
+		canceled <- err
+	}()
+	<-started
+
+	live := make(chan error, 1)
+	go func() {
+		_, err := client.VerifyAndParseWebhookRequestContext(context.Background(), newWebhookRequestSignedBy(t, priv, "abc", testOrderWebhookBody))
+		live <- err
+	}()
+	time.Sleep(10 * time.Millisecond)
+	cancel()
+
+	assert.ErrorIs(t, <-canceled, context.Canceled)
+	err := <-live
+	assert.Nil(t, err, err)
+}
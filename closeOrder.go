@@ -12,13 +12,17 @@ type CloseOrderRequest struct {
 }
 
 func (q *CloseOrderRequest) Validate() error {
-	return validate.Struct(q)
+	return validateStruct(q)
 }
 
 func (q *CloseOrderRequest) EndPoint() string {
 	return "/binancepay/openapi/order/close"
 }
 
+func (q *CloseOrderRequest) Idempotent() bool {
+	return true
+}
+
 // CloseOrderResult
 // equals to true when status="SUCCESS"，which is close request is accepted，
 //   and successful close result will be notified asynchronously through Order Notification Webhook
@@ -0,0 +1,139 @@
+// Command binancepay is an operator tool for the binance pay API.
+//
+// Credentials are read from the environment:
+//
+//	BINANCEPAY_API_KEY   merchant api key
+//	BINANCEPAY_SECRET    merchant secret key
+//	BINANCEPAY_HOST      optional, defaults to binancepay.DefaultHost
+//
+// Usage:
+//
+//	binancepay [-o json|table] order create -trade-no NO -amount AMOUNT -currency USDT -goods-name NAME
+//	binancepay [-o json|table] order query (-trade-no NO | -prepay-id ID)
+//	binancepay [-o json|table] order close (-trade-no NO | -prepay-id ID)
+//	binancepay [-o json|table] certs
+//	binancepay [-o json|table] webhook verify -file FILE [-cert PEM_FILE]
+//	binancepay [-o json|table] sign (-body BODY | -body-file FILE) [-timestamp MILLIS] [-nonce NONCE]
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/dmitrorezn/go-binancepay"
+	"io"
+	"os"
+	"os/signal"
+)
+
+const usage = `usage: binancepay [-o json|table] <command> [flags]
+
+commands:
+  order create   create an order
+  order query    query an order
+  order close    close an order
+  certs          fetch the binance pay certificates
+  webhook verify verify a captured webhook request
+  sign           compute the HMAC signature of a request body
+
+environment:
+  BINANCEPAY_API_KEY, BINANCEPAY_SECRET, BINANCEPAY_HOST
+`
+
+var errUsage = errors.New("invalid usage")
+
+type cli struct {
+	stdout io.Writer
+	stderr io.Writer
+	getenv func(string) string
+	output string
+}
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	c := &cli{stdout: os.Stdout, stderr: os.Stderr, getenv: os.Getenv}
+	if err := c.run(ctx, os.Args[1:]); err != nil {
+		if !errors.Is(err, errUsage) {
+			fmt.Fprintln(os.Stderr, "error:", err)
+		}
+		os.Exit(1)
+	}
+}
+
+func (c *cli) run(ctx context.Context, args []string) error {
+	fs := c.flagSet("binancepay")
+	fs.StringVar(&c.output, "o", "json", "output format: json or table")
+	fs.Usage = func() { fmt.Fprint(c.stderr, usage) }
+	if err := fs.Parse(args); err != nil {
+		return errUsage
+	}
+	if c.output != "json" && c.output != "table" {
+		return c.usageError("unknown output format %q", c.output)
+	}
+
+	args = fs.Args()
+	if len(args) == 0 {
+		return c.usageError("missing command")
+	}
+	switch args[0] {
+	case "order":
+		if len(args) < 2 {
+			return c.usageError("missing order command")
+		}
+		switch args[1] {
+		case "create":
+			return c.orderCreate(ctx, args[2:])
+		case "query":
+			return c.orderQuery(ctx, args[2:])
+		case "close":
+			return c.orderClose(ctx, args[2:])
+		}
+		return c.usageError("unknown order command %q", args[1])
+	case "certs":
+		return c.certs(ctx, args[1:])
+	case "webhook":
+		if len(args) < 2 || args[1] != "verify" {
+			return c.usageError("missing webhook command")
+		}
+		return c.webhookVerify(ctx, args[2:])
+	case "sign":
+		return c.sign(args[1:])
+	}
+	return c.usageError("unknown command %q", args[0])
+}
+
+func (c *cli) flagSet(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(c.stderr)
+	return fs
+}
+
+func (c *cli) usageError(format string, args ...interface{}) error {
+	fmt.Fprintf(c.stderr, "error: "+format+"\n\n", args...)
+	fmt.Fprint(c.stderr, usage)
+	return errUsage
+}
+
+func (c *cli) credentials() (apiKey, secret string, err error) {
+	apiKey = c.getenv("BINANCEPAY_API_KEY")
+	secret = c.getenv("BINANCEPAY_SECRET")
+	if apiKey == "" || secret == "" {
+		return "", "", fmt.Errorf("BINANCEPAY_API_KEY and BINANCEPAY_SECRET must be set")
+	}
+	return apiKey, secret, nil
+}
+
+func (c *cli) merchant(cache binancepay.Cache) (*binancepay.Merchant, error) {
+	apiKey, secret, err := c.credentials()
+	if err != nil {
+		return nil, err
+	}
+	var opts []binancepay.Option
+	if host := c.getenv("BINANCEPAY_HOST"); host != "" {
+		opts = append(opts, binancepay.WithHost(host))
+	}
+	return binancepay.NewMerchant(apiKey, secret, cache, nil, opts...), nil
+}
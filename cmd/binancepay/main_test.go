@@ -0,0 +1,119 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"github.com/dmitrorezn/go-binancepay"
+	"github.com/dmitrorezn/go-binancepay/binancepaytest"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"net/http/httputil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func runCli(t *testing.T, env map[string]string, args ...string) (stdout string, err error) {
+	var out, errOut bytes.Buffer
+	c := &cli{
+		stdout: &out,
+		stderr: &errOut,
+		getenv: func(key string) string { return env[key] },
+	}
+	err = c.run(context.Background(), args)
+	return out.String(), err
+}
+
+func TestSign(t *testing.T) {
+	env := map[string]string{"BINANCEPAY_SECRET": "test secret key"}
+	out, err := runCli(t, env, "sign", "-body", "body", "-timestamp", "1654943252000", "-nonce", "5f14d51f62136d24c87480faa9009d7d")
+	assert.Nil(t, err, err)
+
+	expected, _ := binancepay.Sign([]byte("test secret key"), []byte(binancepay.BuildPayload("body", "1654943252000", "5f14d51f62136d24c87480faa9009d7d")))
+	var got map[string]string
+	assert.Nil(t, json.Unmarshal([]byte(out), &got))
+	assert.Equal(t, expected, got["BinancePay-Signature"])
+
+	out, err = runCli(t, env, "-o", "table", "sign", "-body", "body", "-timestamp", "1654943252000", "-nonce", "abc")
+	assert.Nil(t, err, err)
+	assert.Contains(t, out, "BinancePay-Nonce      abc\n")
+
+	_, err = runCli(t, env, "sign")
+	assert.ErrorIs(t, err, errUsage)
+	_, err = runCli(t, env, "unknown")
+	assert.ErrorIs(t, err, errUsage)
+}
+
+func TestOrderCommands(t *testing.T) {
+	srv := binancepaytest.NewServer("api-key", "secret")
+	defer srv.Close()
+	env := map[string]string{
+		"BINANCEPAY_API_KEY": "api-key",
+		"BINANCEPAY_SECRET":  "secret",
+		"BINANCEPAY_HOST":    srv.URL,
+	}
+
+	_, err := runCli(t, env, "order", "create", "-trade-no", "order1", "-amount", "10", "-goods-name", "credits")
+	assert.Nil(t, err, err)
+
+	out, err := runCli(t, env, "-o", "table", "order", "query", "-trade-no", "order1")
+	assert.Nil(t, err, err)
+	assert.Contains(t, out, "merchantTradeNo  order1\n")
+	assert.Contains(t, out, "status           INITIAL\n")
+
+	out, err = runCli(t, env, "order", "close", "-trade-no", "order1")
+	assert.Nil(t, err, err)
+	assert.Contains(t, out, `"accepted": true`)
+
+	out, err = runCli(t, env, "-o", "table", "certs")
+	assert.Nil(t, err, err)
+	assert.True(t, strings.HasPrefix(out, "CERTPUBLIC"), out)
+	assert.Contains(t, out, srv.Certificate().CertSerial)
+
+	_, err = runCli(t, env, "order", "query", "-trade-no", "missing")
+	assert.True(t, binancepay.IsNotFound(err), err)
+
+	_, err = runCli(t, map[string]string{}, "certs")
+	assert.NotNil(t, err)
+}
+
+func TestWebhookVerify(t *testing.T) {
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.Nil(t, err, err)
+	signer := binancepay.NewWebhookSigner(privateKey, "serial-1")
+	cert, err := signer.Certificate()
+	assert.Nil(t, err, err)
+
+	req, err := signer.NewOrderRequest("http://localhost/webhook", "1", "PAY_SUCCESS", &binancepay.OrderNoti{MerchantTradeNo: "order1"})
+	assert.Nil(t, err, err)
+	raw, err := httputil.DumpRequest(req, true)
+	assert.Nil(t, err, err)
+
+	dir := t.TempDir()
+	webhookFile := filepath.Join(dir, "webhook.http")
+	certFile := filepath.Join(dir, "cert.pem")
+	assert.Nil(t, os.WriteFile(webhookFile, raw, 0o600))
+	assert.Nil(t, os.WriteFile(certFile, []byte(cert.CertPublic), 0o600))
+
+	out, err := runCli(t, nil, "webhook", "verify", "-file", webhookFile, "-cert", certFile)
+	assert.Nil(t, err, err)
+	assert.Contains(t, out, `"merchantTradeNo": "order1"`)
+	assert.Contains(t, out, `"bizStatus": "PAY_SUCCESS"`)
+
+	// a failed verification doesn't refetch the certificates when -cert is set
+	requests := 0
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { requests++ }))
+	defer api.Close()
+	env := map[string]string{"BINANCEPAY_API_KEY": "key", "BINANCEPAY_SECRET": "secret", "BINANCEPAY_HOST": api.URL}
+
+	tampered := bytes.Replace(raw, []byte("order1"), []byte("order2"), 1)
+	assert.Nil(t, os.WriteFile(webhookFile, tampered, 0o600))
+	_, err = runCli(t, env, "webhook", "verify", "-file", webhookFile, "-cert", certFile)
+	assert.NotNil(t, err)
+	assert.Equal(t, 0, requests)
+}
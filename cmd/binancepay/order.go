@@ -0,0 +1,120 @@
+package main
+
+import (
+	"context"
+	"github.com/dmitrorezn/go-binancepay"
+	"github.com/shopspring/decimal"
+)
+
+func (c *cli) orderCreate(ctx context.Context, args []string) error {
+	fs := c.flagSet("order create")
+	tradeNo := fs.String("trade-no", "", "merchantTradeNo, generated when empty")
+	amount := fs.String("amount", "", "order amount")
+	currency := fs.String("currency", "USDT", "order currency")
+	terminal := fs.String("terminal", "WEB", "terminal type: APP, WEB, WAP, MINI_PROGRAM or OTHERS")
+	goodsType := fs.String("goods-type", "02", "goods type: 01 tangible, 02 virtual")
+	goodsCategory := fs.String("goods-category", "Z000", "goods category code")
+	goodsId := fs.String("goods-id", "", "referenceGoodsId, defaults to merchantTradeNo")
+	goodsName := fs.String("goods-name", "", "goods name")
+	expiresIn := fs.Duration("expires-in", 0, "order expire duration, e.g. 15m")
+	returnUrl := fs.String("return-url", "", "url to redirect to after payment")
+	cancelUrl := fs.String("cancel-url", "", "url to redirect to when the payment is canceled")
+	if err := fs.Parse(args); err != nil {
+		return errUsage
+	}
+	if *amount == "" || *goodsName == "" {
+		return c.usageError("-amount and -goods-name are required")
+	}
+	orderAmount, err := decimal.NewFromString(*amount)
+	if err != nil {
+		return c.usageError("invalid -amount: %v", err)
+	}
+	req, err := binancepay.NewOrderBuilder().
+		MerchantTradeNo(*tradeNo).
+		Amount(orderAmount, *currency).
+		Terminal(*terminal).
+		Goods(*goodsId, *goodsName).
+		GoodsType(*goodsType).
+		Category(*goodsCategory).
+		ExpiresIn(*expiresIn).
+		ReturnTo(*returnUrl).
+		CancelTo(*cancelUrl).
+		Build()
+	if err != nil {
+		return err
+	}
+
+	m, err := c.merchant(nil)
+	if err != nil {
+		return err
+	}
+	var resp binancepay.Response[binancepay.CreateOrderV2Result]
+	if err = m.DoContext(ctx, req, &resp); err != nil {
+		return err
+	}
+	return c.print(resp.Data)
+}
+
+func (c *cli) orderRef(name string, args []string) (tradeNo, prepayId string, err error) {
+	fs := c.flagSet(name)
+	fs.StringVar(&tradeNo, "trade-no", "", "merchantTradeNo")
+	fs.StringVar(&prepayId, "prepay-id", "", "prepayId")
+	if err = fs.Parse(args); err != nil {
+		return "", "", errUsage
+	}
+	if (tradeNo == "") == (prepayId == "") {
+		return "", "", c.usageError("exactly one of -trade-no and -prepay-id is required")
+	}
+	return tradeNo, prepayId, nil
+}
+
+func (c *cli) orderQuery(ctx context.Context, args []string) error {
+	tradeNo, prepayId, err := c.orderRef("order query", args)
+	if err != nil {
+		return err
+	}
+	m, err := c.merchant(nil)
+	if err != nil {
+		return err
+	}
+	var resp binancepay.Response[binancepay.QueryOrderResult]
+	if err = m.DoContext(ctx, &binancepay.QueryOrderRequest{MerchantTradeNo: tradeNo, PrepayId: prepayId}, &resp); err != nil {
+		return err
+	}
+	return c.print(resp.Data)
+}
+
+func (c *cli) orderClose(ctx context.Context, args []string) error {
+	tradeNo, prepayId, err := c.orderRef("order close", args)
+	if err != nil {
+		return err
+	}
+	m, err := c.merchant(nil)
+	if err != nil {
+		return err
+	}
+	var resp binancepay.Response[binancepay.CloseOrderResult]
+	if err = m.DoContext(ctx, &binancepay.CloseOrderRequest{MerchantTradeNo: tradeNo, PrepayId: prepayId}, &resp); err != nil {
+		return err
+	}
+	return c.print(map[string]interface{}{
+		"accepted": bool(resp.Data),
+		"message":  "close request accepted, the result is notified through the order webhook",
+	})
+}
+
+func (c *cli) certs(ctx context.Context, args []string) error {
+	fs := c.flagSet("certs")
+	if err := fs.Parse(args); err != nil {
+		return errUsage
+	}
+	m, err := c.merchant(nil)
+	if err != nil {
+		return err
+	}
+	var resp binancepay.Response[binancepay.QueryCertificateResult]
+	if err = m.DoContext(ctx, &binancepay.QueryCertificateRequest{}, &resp); err != nil {
+		return err
+	}
+	return c.print(resp.Data)
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"text/tabwriter"
+)
+
+// print writes v as indented JSON, or as a table when -o table is set.
+func (c *cli) print(v interface{}) error {
+	b, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	if c.output == "json" {
+		_, err = fmt.Fprintln(c.stdout, string(b))
+		return err
+	}
+
+	var generic interface{}
+	if err = json.Unmarshal(b, &generic); err != nil {
+		return err
+	}
+	w := tabwriter.NewWriter(c.stdout, 0, 0, 2, ' ', 0)
+	switch t := generic.(type) {
+	case map[string]interface{}:
+		for _, key := range sortedKeys(t) {
+			fmt.Fprintf(w, "%s\t%s\n", key, cell(t[key]))
+		}
+	case []interface{}:
+		var columns []string
+		for _, row := range t {
+			if obj, ok := row.(map[string]interface{}); ok {
+				columns = sortedKeys(obj)
+				break
+			}
+		}
+		fmt.Fprintln(w, strings.ToUpper(strings.Join(columns, "\t")))
+		for _, row := range t {
+			obj, _ := row.(map[string]interface{})
+			cells := make([]string, len(columns))
+			for i, column := range columns {
+				cells[i] = cell(obj[column])
+			}
+			fmt.Fprintln(w, strings.Join(cells, "\t"))
+		}
+	default:
+		fmt.Fprintln(w, cell(t))
+	}
+	return w.Flush()
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// cell renders a table cell on a single line.
+func cell(v interface{}) string {
+	switch t := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return strings.ReplaceAll(t, "\n", `\n`)
+	case map[string]interface{}, []interface{}:
+		b, _ := json.Marshal(t)
+		return string(b)
+	}
+	return fmt.Sprint(v)
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"github.com/dmitrorezn/go-binancepay"
+	"os"
+	"strconv"
+	"time"
+)
+
+// sign computes the BinancePay-Signature header of a request body, to debug signature errors.
+func (c *cli) sign(args []string) error {
+	fs := c.flagSet("sign")
+	body := fs.String("body", "", "request body")
+	bodyFile := fs.String("body-file", "", "file holding the request body")
+	timestamp := fs.String("timestamp", "", "BinancePay-Timestamp in milliseconds, defaults to now")
+	nonce := fs.String("nonce", "", "BinancePay-Nonce, generated when empty")
+	if err := fs.Parse(args); err != nil {
+		return errUsage
+	}
+	if (*body == "") == (*bodyFile == "") {
+		return c.usageError("exactly one of -body and -body-file is required")
+	}
+	if *bodyFile != "" {
+		b, err := os.ReadFile(*bodyFile)
+		if err != nil {
+			return err
+		}
+		*body = string(b)
+	}
+	if *timestamp == "" {
+		*timestamp = strconv.FormatInt(time.Now().UnixMilli(), 10)
+	}
+	if *nonce == "" {
+		*nonce = binancepay.Nonce()
+	}
+
+	secret := c.getenv("BINANCEPAY_SECRET")
+	if secret == "" {
+		return c.usageError("BINANCEPAY_SECRET must be set")
+	}
+	payload := binancepay.BuildPayload(*body, *timestamp, *nonce)
+	signature, err := binancepay.Sign([]byte(secret), []byte(payload))
+	if err != nil {
+		return err
+	}
+	return c.print(map[string]interface{}{
+		"BinancePay-Timestamp": *timestamp,
+		"BinancePay-Nonce":     *nonce,
+		"BinancePay-Signature": signature,
+	})
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/dmitrorezn/go-binancepay"
+	"io"
+	"net/http"
+	"os"
+)
+
+// webhookVerify verifies a webhook request captured in raw HTTP/1.1 form, e.g. with httputil.DumpRequest.
+// The certificates are fetched from the API unless -cert provides the PEM public key,
+// the API is never called then.
+func (c *cli) webhookVerify(ctx context.Context, args []string) error {
+	fs := c.flagSet("webhook verify")
+	file := fs.String("file", "", "file holding the raw HTTP request of the webhook")
+	certFile := fs.String("cert", "", "optional file holding the PEM public key of the binance pay certificate")
+	if err := fs.Parse(args); err != nil {
+		return errUsage
+	}
+	if *file == "" {
+		return c.usageError("-file is required")
+	}
+
+	raw, err := os.ReadFile(*file)
+	if err != nil {
+		return err
+	}
+	br := bufio.NewReader(bytes.NewReader(raw))
+	httpReq, err := http.ReadRequest(br)
+	if err != nil {
+		return fmt.Errorf("http.ReadRequest(%s): %w", *file, err)
+	}
+	if httpReq.ContentLength <= 0 && len(httpReq.TransferEncoding) == 0 {
+		// captures often lack Content-Length, the body is whatever follows the headers then
+		body, err := io.ReadAll(br)
+		if err != nil {
+			return err
+		}
+		httpReq.Body = io.NopCloser(bytes.NewReader(body))
+	}
+
+	var m *binancepay.Merchant
+	if *certFile != "" {
+		pem, err := os.ReadFile(*certFile)
+		if err != nil {
+			return err
+		}
+		if _, err = binancepay.ParsePublicKey(string(pem)); err != nil {
+			return fmt.Errorf("%s: %w", *certFile, err)
+		}
+		cert := binancepay.Certificate{
+			CertSerial: httpReq.Header.Get("BinancePay-Certificate-SN"),
+			CertPublic: string(pem),
+		}
+		m = binancepay.NewMerchant("", "", nil, nil,
+			binancepay.WithCertificates(cert),
+			binancepay.WithHTTPClient(&http.Client{Transport: offlineTransport{}}),
+		)
+	} else if m, err = c.merchant(nil); err != nil {
+		return err
+	}
+
+	rawReq, err := m.VerifyAndParseWebhookRequestContext(ctx, httpReq)
+	if err != nil {
+		return fmt.Errorf("invalid webhook: %w", err)
+	}
+
+	var data interface{} = rawReq.RawData
+	var decoded interface{}
+	if json.Unmarshal([]byte(rawReq.RawData), &decoded) == nil {
+		data = decoded
+	}
+	return c.print(map[string]interface{}{
+		"valid":     true,
+		"bizType":   rawReq.BizType,
+		"bizId":     rawReq.BizId,
+		"bizStatus": rawReq.BizStatus,
+		"data":      data,
+	})
+}
+
+// offlineTransport fails every request, it keeps a merchant with -cert from refetching the certificates.
+type offlineTransport struct{}
+
+func (offlineTransport) RoundTrip(*http.Request) (*http.Response, error) {
+	return nil, errors.New("certificates are not fetched when -cert is set")
+}
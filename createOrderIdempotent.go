@@ -0,0 +1,175 @@
+package binancepay
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go.uber.org/zap"
+	"io"
+	"net"
+	"net/url"
+	"time"
+)
+
+// DefaultCreateOrderStoreTTL is how long NewCacheCreateOrderStore remembers created orders.
+const DefaultCreateOrderStoreTTL = 24 * time.Hour
+
+var (
+	// ErrOrderConflict is returned by CreateOrderIdempotent when an order with the same merchantTradeNo
+	// but another amount or currency exists.
+	ErrOrderConflict = errors.New("merchantTradeNo used by another order")
+	// ErrOrderNotPayable is returned by CreateOrderIdempotent when the existing order was canceled, expired or failed.
+	ErrOrderNotPayable = errors.New("order is no longer payable")
+)
+
+// CreateOrderStore remembers the results of created orders, see WithCreateOrderStore.
+type CreateOrderStore interface {
+	LoadCreateOrder(ctx context.Context, merchantTradeNo string) (result *CreateOrderV2Result, ok bool, err error)
+	SaveCreateOrder(ctx context.Context, merchantTradeNo string, result *CreateOrderV2Result) error
+}
+
+type cacheCreateOrderStore struct {
+	cache Cache
+	ttl   time.Duration
+}
+
+// NewCacheCreateOrderStore returns a CreateOrderStore backed by cache, ttl defaults to DefaultCreateOrderStoreTTL.
+func NewCacheCreateOrderStore(cache Cache, ttl time.Duration) CreateOrderStore {
+	if ttl <= 0 {
+		ttl = DefaultCreateOrderStoreTTL
+	}
+	return &cacheCreateOrderStore{cache: cache, ttl: ttl}
+}
+
+func (s *cacheCreateOrderStore) key(merchantTradeNo string) string {
+	return "binance-pay:create-order:" + merchantTradeNo
+}
+
+func (s *cacheCreateOrderStore) LoadCreateOrder(ctx context.Context, merchantTradeNo string) (*CreateOrderV2Result, bool, error) {
+	var result CreateOrderV2Result
+	exists, err := s.cache.GetJSON(ctx, s.key(merchantTradeNo), &result)
+	if err != nil {
+		return nil, false, fmt.Errorf("cache.GetJSON(): %w", err)
+	}
+	if !exists {
+		return nil, false, nil
+	}
+	return &result, true, nil
+}
+
+func (s *cacheCreateOrderStore) SaveCreateOrder(ctx context.Context, merchantTradeNo string, result *CreateOrderV2Result) error {
+	if err := s.cache.SetJSON(ctx, s.key(merchantTradeNo), result, s.ttl); err != nil {
+		return fmt.Errorf("cache.SetJSON(): %w", err)
+	}
+	return nil
+}
+
+// CreateOrderIdempotent creates the order of req, or returns the checkout data of the order
+// already created with the same MerchantTradeNo. It is safe to call again after a failure.
+//
+// When creating fails ambiguously, e.g. on a transport failure or timeout of the http client or a server error, or with a
+// duplicate merchantTradeNo, the order is queried by MerchantTradeNo. An order which doesn't exist is created again,
+// at most once per call. An existing order with another amount or currency fails with ErrOrderConflict,
+// a canceled, expired or failed one with ErrOrderNotPayable.
+//
+// Created orders are remembered in the store of WithCreateOrderStore, repeated calls return the original result.
+// Orders recovered by a query are not stored, their ExpireTime is the OrderExpireTime of req, 0 when not set.
+func (m *Merchant) CreateOrderIdempotent(ctx context.Context, req *CreateOrderV2Request) (*CreateOrderV2Result, error) {
+	if err := m.validateRequest(req); err != nil {
+		return nil, err
+	}
+	logger := m.logger.With(zap.String("merchantTradeNo", req.MerchantTradeNo))
+
+	if m.createOrderStore != nil {
+		result, ok, err := m.createOrderStore.LoadCreateOrder(ctx, req.MerchantTradeNo)
+		if err != nil {
+			return nil, fmt.Errorf("LoadCreateOrder(): %w", err)
+		}
+		if ok {
+			return result, nil
+		}
+	}
+
+	result, err := m.createOrder(ctx, req)
+	recovered := false
+	for created := 1; err != nil; created++ {
+		if !ambiguousCreateError(err) || ctx.Err() != nil {
+			return nil, err
+		}
+		logger.Warn("order creation failed, query existing order", zap.Error(err))
+
+		existing, queryErr := m.queryCreatedOrder(ctx, req)
+		switch {
+		case queryErr == nil:
+			result, err = existing, nil
+			recovered = true
+		case IsNotFound(queryErr) && !IsDuplicateOrder(err) && created < 2:
+			result, err = m.createOrder(ctx, req)
+		case IsNotFound(queryErr):
+			return nil, err
+		default:
+			return nil, fmt.Errorf("queryOrder(): %w", errors.Join(err, queryErr))
+		}
+	}
+
+	// the query doesn't return the expire time, so a recovered result isn't the original one
+	if m.createOrderStore != nil && !recovered {
+		if err = m.createOrderStore.SaveCreateOrder(ctx, req.MerchantTradeNo, result); err != nil {
+			logger.Warn("failed to save created order", zap.Error(err))
+		}
+	}
+	return result, nil
+}
+
+func (m *Merchant) createOrder(ctx context.Context, req *CreateOrderV2Request) (*CreateOrderV2Result, error) {
+	var resp Response[CreateOrderV2Result]
+	if err := m.DoContext(ctx, req, &resp); err != nil {
+		return nil, err
+	}
+	return &resp.Data, nil
+}
+
+// queryCreatedOrder returns the checkout data of the existing order of req.
+func (m *Merchant) queryCreatedOrder(ctx context.Context, req *CreateOrderV2Request) (*CreateOrderV2Result, error) {
+	var resp Response[QueryOrderResult]
+	if err := m.DoContext(ctx, &QueryOrderRequest{MerchantTradeNo: req.MerchantTradeNo}, &resp); err != nil {
+		return nil, err
+	}
+	order := resp.Data
+
+	amount, err := order.Money()
+	if err != nil {
+		return nil, fmt.Errorf("order.Money(): %w", err)
+	}
+	if !amount.Equal(req.Money()) {
+		return nil, fmt.Errorf("order=%s: %w", amount, ErrOrderConflict)
+	}
+	if order.Status.Terminal() {
+		return nil, fmt.Errorf("status=%s: %w", order.Status, ErrOrderNotPayable)
+	}
+
+	return &CreateOrderV2Result{
+		PrepayId:     order.PrepayId,
+		TerminalType: req.Env.TerminalType,
+		ExpireTime:   req.OrderExpireTime,
+		QrcodeLink:   order.QrcodeLink,
+		QrContent:    order.QrContent,
+		CheckoutUrl:  order.CheckoutUrl,
+		Deeplink:     order.Deeplink,
+		UniversalUrl: order.UniversalUrl,
+	}, nil
+}
+
+// ambiguousCreateError reports whether the order may exist although creating it failed.
+// Local failures, e.g. of signing the request, are not ambiguous.
+func ambiguousCreateError(err error) bool {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return IsDuplicateOrder(err) || IsRetryable(err)
+	}
+	// the request may have been sent before the transport failed or timed out
+	var urlErr *url.Error
+	var netErr net.Error
+	return errors.As(err, &urlErr) || errors.As(err, &netErr) ||
+		errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, context.DeadlineExceeded)
+}
@@ -0,0 +1,153 @@
+package binancepay
+
+import (
+	"context"
+	"errors"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"testing"
+)
+
+const (
+	testCreatedOrderBody  = `{"status":"SUCCESS","code":"000000","data":{"prepayId":"1234","terminalType":"WEB","expireTime":1655859345000,"checkoutUrl":"https://pay.binance.com/checkout/1234"}}`
+	testDuplicateBody     = `{"status":"FAIL","code":"400201","errorMessage":"merchantTradeNo is duplicated"}`
+	testExistingOrderBody = `{"status":"SUCCESS","code":"000000","data":{"prepayId":"1234","merchantTradeNo":"9825382937292","status":"INITIAL","currency":"USDT","orderAmount":"0.88000000","checkoutUrl":"https://pay.binance.com/checkout/1234"}}`
+	testOrderNotFoundBody = `{"status":"FAIL","code":"400202","errorMessage":"order not found"}`
+)
+
+func TestCreateOrderIdempotent_DuplicateFallsBackToQuery(t *testing.T) {
+	var paths []string
+	m := NewMerchant("", "", nil, logger, WithClock(testClock), WithHTTPClient(orderEndpointsHttpClient(
+		func() (int, string, error) { return http.StatusOK, testDuplicateBody, nil },
+		func() (int, string, error) { return http.StatusOK, testExistingOrderBody, nil },
+		&paths,
+	)))
+
+	result, err := m.CreateOrderIdempotent(context.Background(), newTestOrderRequest())
+	assert.Nil(t, err, err)
+	assert.Equal(t, &CreateOrderV2Result{
+		PrepayId:     "1234",
+		TerminalType: "WEB",
+		ExpireTime:   testOrderExpireTime,
+		CheckoutUrl:  "https://pay.binance.com/checkout/1234",
+	}, result)
+	assert.Equal(t, []string{"/binancepay/openapi/v2/order", "/binancepay/openapi/v2/order/query"}, paths)
+}
+
+func TestCreateOrderIdempotent_AmbiguousFailure(t *testing.T) {
+	var paths []string
+	creates := 0
+	m := NewMerchant("", "", nil, logger, WithClock(testClock), WithHTTPClient(orderEndpointsHttpClient(
+		func() (int, string, error) {
+			creates++
+			if creates == 1 {
+				return 0, "", errors.New("i/o timeout")
+			}
+			return http.StatusOK, testCreatedOrderBody, nil
+		},
+		func() (int, string, error) { return http.StatusOK, testOrderNotFoundBody, nil },
+		&paths,
+	)))
+
+	// the order wasn't created, so it's created again
+	result, err := m.CreateOrderIdempotent(context.Background(), newTestOrderRequest())
+	assert.Nil(t, err, err)
+	assert.Equal(t, "1234", result.PrepayId)
+	assert.Equal(t, []string{
+		"/binancepay/openapi/v2/order",
+		"/binancepay/openapi/v2/order/query",
+		"/binancepay/openapi/v2/order",
+	}, paths)
+}
+
+func TestCreateOrderIdempotent_Errors(t *testing.T) {
+	var paths []string
+	createBody, queryBody := testDuplicateBody, testExistingOrderBody
+	m := NewMerchant("", "", nil, logger, WithClock(testClock), WithHTTPClient(orderEndpointsHttpClient(
+		func() (int, string, error) { return http.StatusOK, createBody, nil },
+		func() (int, string, error) { return http.StatusOK, queryBody, nil },
+		&paths,
+	)))
+
+	req := newTestOrderRequest()
+	req.OrderAmount = decimal.RequireFromString("1")
+	_, err := m.CreateOrderIdempotent(context.Background(), req)
+	assert.ErrorIs(t, err, ErrOrderConflict)
+
+	queryBody = `{"status":"SUCCESS","code":"000000","data":{"merchantTradeNo":"9825382937292","status":"EXPIRED","currency":"USDT","orderAmount":"0.88"}}`
+	_, err = m.CreateOrderIdempotent(context.Background(), newTestOrderRequest())
+	assert.ErrorIs(t, err, ErrOrderNotPayable)
+
+	// definite rejections aren't followed by a query
+	paths = nil
+	createBody = `{"status":"FAIL","code":"400002","errorMessage":"invalid signature"}`
+	_, err = m.CreateOrderIdempotent(context.Background(), newTestOrderRequest())
+	assert.True(t, IsSignatureError(err), err)
+	assert.Equal(t, []string{"/binancepay/openapi/v2/order"}, paths)
+}
+
+func TestCreateOrderIdempotent_Store(t *testing.T) {
+	var paths []string
+	m := NewMerchant("", "", nil, logger,
+		WithClock(testClock),
+		WithHTTPClient(orderEndpointsHttpClient(
+			func() (int, string, error) { return http.StatusOK, testCreatedOrderBody, nil },
+			func() (int, string, error) { return http.StatusOK, testExistingOrderBody, nil },
+			&paths,
+		)),
+		WithCreateOrderStore(NewCacheCreateOrderStore(NewMemoryCache(0), 0)),
+	)
+
+	first, err := m.CreateOrderIdempotent(context.Background(), newTestOrderRequest())
+	assert.Nil(t, err, err)
+	second, err := m.CreateOrderIdempotent(context.Background(), newTestOrderRequest())
+	assert.Nil(t, err, err)
+	assert.Equal(t, first, second)
+	assert.Equal(t, int64(1655859345000), second.ExpireTime)
+	assert.Equal(t, []string{"/binancepay/openapi/v2/order"}, paths)
+}
+
+func TestCreateOrderIdempotent_LocalFailure(t *testing.T) {
+	var paths []string
+	m := NewMerchant("", "", nil, logger,
+		WithClock(testClock),
+		WithHTTPClient(orderEndpointsHttpClient(
+			func() (int, string, error) { return http.StatusOK, testCreatedOrderBody, nil },
+			func() (int, string, error) { return http.StatusOK, testExistingOrderBody, nil },
+			&paths,
+		)),
+		WithInterceptors(func(ctx context.Context, call *Call, next Invoker) error {
+			return errors.New("signing key unavailable")
+		}),
+	)
+
+	// the request was never sent, so there is no order to query
+	_, err := m.CreateOrderIdempotent(context.Background(), newTestOrderRequest())
+	assert.EqualError(t, err, "signing key unavailable")
+	assert.Empty(t, paths)
+}
+
+func TestCreateOrderIdempotent_RecoveredNotStored(t *testing.T) {
+	var paths []string
+	m := NewMerchant("", "", nil, logger,
+		WithClock(testClock),
+		WithHTTPClient(orderEndpointsHttpClient(
+			func() (int, string, error) { return http.StatusOK, testDuplicateBody, nil },
+			func() (int, string, error) { return http.StatusOK, testExistingOrderBody, nil },
+			&paths,
+		)),
+		WithCreateOrderStore(NewCacheCreateOrderStore(NewMemoryCache(0), 0)),
+	)
+
+	req := newTestOrderRequest()
+	req.OrderExpireTime = 0
+	result, err := m.CreateOrderIdempotent(context.Background(), req)
+	assert.Nil(t, err, err)
+	assert.Equal(t, "1234", result.PrepayId)
+
+	// the recovered result lacks the expire time, so the order is queried again
+	_, err = m.CreateOrderIdempotent(context.Background(), req)
+	assert.Nil(t, err, err)
+	assert.Len(t, paths, 4)
+}
@@ -5,7 +5,13 @@
 package binancepay
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/go-playground/validator/v10"
 	"github.com/shopspring/decimal"
+	"regexp"
+	"time"
 )
 
 var _ IRequest = &CreateOrderV2Request{}
@@ -21,63 +27,150 @@ type Env struct {
 	CookieId      string `json:"cookieId,omitempty"`
 }
 
-type Amount struct {
-	Currency string          `json:"currency" validate:"required"`
-	Amount   decimal.Decimal `json:"amount" validate:"required"`
+const (
+	TerminalTypeApp         = "APP"
+	TerminalTypeWeb         = "WEB"
+	TerminalTypeWap         = "WAP"
+	TerminalTypeMiniProgram = "MINI_PROGRAM"
+	TerminalTypeOthers      = "OTHERS"
+)
+
+// values of Goods.GoodsType
+const (
+	GoodsTypeTangible = "01"
+	GoodsTypeVirtual  = "02"
+)
+
+// values of Goods.GoodsCategory
+const (
+	CategoryElectronics     = "0000" // Electronics & Computers
+	CategoryBooks           = "1000" // Books, Music & Movies
+	CategoryHome            = "2000" // Home, Garden & Tools
+	CategoryClothes         = "3000" // Clothes, Shoes & Bags
+	CategoryToys            = "4000" // Toys, Kids & Baby
+	CategoryAutomotive      = "5000" // Automotive & Accessories
+	CategoryGames           = "6000" // Game & Recharge
+	CategoryEntertainment   = "7000" // Entertainament & Collection
+	CategoryJewelry         = "8000" // Jewelry
+	CategoryDomesticService = "9000" // Domestic service
+	CategoryBeauty          = "A000" // Beauty care
+	CategoryPharmacy        = "B000" // Pharmacy
+	CategorySports          = "C000" // Sports & Outdoors
+	CategoryFood            = "D000" // Food, Grocery & Health products
+	CategoryPets            = "E000" // Pet supplies
+	CategoryIndustry        = "F000" // Industry & Science
+	CategoryOthers          = "Z000" // Others
+)
+
+// ValidGoodsCategory reports whether category is a documented goods category.
+func ValidGoodsCategory(category string) bool {
+	switch category {
+	case CategoryElectronics, CategoryBooks, CategoryHome, CategoryClothes, CategoryToys, CategoryAutomotive,
+		CategoryGames, CategoryEntertainment, CategoryJewelry, CategoryDomesticService, CategoryBeauty,
+		CategoryPharmacy, CategorySports, CategoryFood, CategoryPets, CategoryIndustry, CategoryOthers:
+		return true
+	}
+	return false
 }
 
 type Goods struct {
-	GoodsType string `json:"goodsType" validate:"required,oneof=01 02"` // "01": Tangible Goods "02": Virtual Goods
-	// GoodsCategory
-	// 0000: Electronics & Computers
-	// 1000: Books, Music & Movies
-	// 2000: Home, Garden & Tools
-	// 3000: Clothes, Shoes & Bags
-	// 4000: Toys, Kids & Baby
-	// 5000: Automotive & Accessories
-	// 6000: Game & Recharge
-	// 7000: Entertainament & Collection
-	// 8000: Jewelry
-	// 9000: Domestic service
-	// A000: Beauty care
-	// B000: Pharmacy
-	// C000: Sports & Outdoors
-	// D000: Food, Grocery & Health products
-	// E000: Pet supplies
-	// F000: Industry & Science
-	// Z000: Others
-	GoodsCategory    string  `json:"goodsCategory" validate:"required"`
-	ReferenceGoodsId string  `json:"referenceGoodsId" validate:"required"` // The unique ID to identify the goods.
-	GoodsName        string  `json:"goodsName" validate:"required"`        // Goods name
-	GoodsDetail      string  `json:"goodsDetail,omitempty"`                // Optional.
-	GoodsUnitAmount  *Amount `json:"goodsUnitAmount,omitempty"`            // Optional.
-	GoodsQuantity    string  `json:"goodsQuantity"`                        // Quantity of goods
+	GoodsType        string `json:"goodsType" validate:"required,oneof=01 02"`        // GoodsTypeTangible or GoodsTypeVirtual
+	GoodsCategory    string `json:"goodsCategory" validate:"required,goods_category"` // one of the Category constants
+	ReferenceGoodsId string `json:"referenceGoodsId" validate:"required"`             // The unique ID to identify the goods.
+	GoodsName        string `json:"goodsName" validate:"required"`                    // Goods name
+	GoodsDetail      string `json:"goodsDetail,omitempty"`                            // Optional.
+	GoodsUnitAmount  *Money `json:"goodsUnitAmount,omitempty"`                        // Optional.
+	GoodsQuantity    string `json:"goodsQuantity" validate:"omitempty,number"`        // Quantity of goods
 }
 
 // CreateOrderV2Request doc https://developers.binance.com/docs/binance-pay/api-order-create-v2#child-attribute
 type CreateOrderV2Request struct {
-	SubMerchant     SubMerchant     `json:"merchant"`
-	Env             Env             `json:"env"`
-	MerchantTradeNo string          `json:"merchantTradeNo" validate:"required"` // maximum length 32
-	OrderAmount     decimal.Decimal `json:"orderAmount" validate:"required"`     // Range: 0.01 - 20000
-	Currency        string          `json:"currency" validate:"required"`        // order currency in upper case. only "BUSD","USDT","MBOX" can be accepted, fiat NOT supported.
-	Goods           Goods           `json:"goods" validate:"required"`
-	//Shipping           string      `json:"shipping"`
-	//Buyer              string      `json:"buyer"`
-	ReturnUrl          string `json:"returnUrl"`
-	CancelUrl          string `json:"cancelUrl"`
-	OrderExpireTime    int64  `json:"orderExpireTime,omitempty"` // milliseconds
-	SupportPayCurrency string `json:"supportPayCurrency"`        //  e.g. "BUSD,BNB"
-	AppId              string `json:"appId,omitempty"`           // This field is required when terminalType is MINI_PROGRAM
-	UniversalUrlAttach string `json:"universalUrlAttach"`
+	SubMerchant        SubMerchant     `json:"merchant"`
+	Env                Env             `json:"env"`
+	MerchantTradeNo    string          `json:"merchantTradeNo" validate:"required,max=32,alphanum"`             // letters and digits, maximum length 32
+	OrderAmount        decimal.Decimal `json:"orderAmount" validate:"required,order_amount,precision=Currency"` // Range: 0.01 - 20000
+	Currency           string          `json:"currency" validate:"required"`                                    // order currency in upper case. only "BUSD","USDT","MBOX" can be accepted, fiat NOT supported.
+	Goods              Goods           `json:"goods" validate:"-"`                                              // Goods or GoodsDetails is required, empty Goods are omitted
+	GoodsDetails       []Goods         `json:"goodsDetails,omitempty" validate:"omitempty,dive"`
+	Shipping           *Shipping       `json:"shipping,omitempty"`
+	Buyer              *Buyer          `json:"buyer,omitempty"`
+	ReturnUrl          string          `json:"returnUrl" validate:"omitempty,url"`
+	CancelUrl          string          `json:"cancelUrl" validate:"omitempty,url"`
+	OrderExpireTime    int64           `json:"orderExpireTime,omitempty" validate:"omitempty,future_millis"` // milliseconds
+	SupportPayCurrency string          `json:"supportPayCurrency" validate:"omitempty,currency_list"`        //  e.g. "BUSD,BNB"
+	AppId              string          `json:"appId,omitempty"`                                              // This field is required when terminalType is MINI_PROGRAM
+	UniversalUrlAttach string          `json:"universalUrlAttach"`
+	OrderTags          *OrderTags      `json:"orderTags,omitempty"`
+	WebhookUrl         string          `json:"webhookUrl,omitempty" validate:"omitempty,url"`          // Overrides the webhook url configured for the merchant.
+	PassThroughInfo    string          `json:"passThroughInfo,omitempty" validate:"omitempty,max=512"` // Returned as is by the order query.
+}
+
+// Name of a buyer or a shipping recipient.
+type Name struct {
+	FirstName  string `json:"firstName" validate:"required"`
+	MiddleName string `json:"middleName,omitempty"`
+	LastName   string `json:"lastName" validate:"required"`
+}
+
+type ShippingAddress struct {
+	Region              string `json:"region" validate:"required,iso3166_1_alpha2"` // ISO 3166 country code, e.g. "SG"
+	State               string `json:"state,omitempty"`
+	City                string `json:"city,omitempty"`
+	Address             string `json:"address,omitempty"`
+	ZipCode             string `json:"zipCode,omitempty"`
+	ShippingAddressType string `json:"shippingAddressType,omitempty" validate:"omitempty,oneof=01 02 03"` // "01": Home "02": Office "03": Others
+}
+
+type Shipping struct {
+	ShippingName    *Name            `json:"shippingName,omitempty"`
+	ShippingAddress *ShippingAddress `json:"shippingAddress,omitempty"`
+	ShippingPhoneNo string           `json:"shippingPhoneNo,omitempty"`
+}
+
+type Buyer struct {
+	ReferenceBuyerId      string `json:"referenceBuyerId,omitempty"`
+	BuyerName             *Name  `json:"buyerName" validate:"required"`
+	BuyerPhoneCountryCode string `json:"buyerPhoneCountryCode,omitempty" validate:"omitempty,number"` // e.g. "65"
+	BuyerPhoneNo          string `json:"buyerPhoneNo,omitempty"`
+	BuyerEmail            string `json:"buyerEmail,omitempty" validate:"omitempty,email"`
+	BuyerRegistrationTime int64  `json:"buyerRegistrationTime,omitempty"` // milliseconds
+	BuyerBrowserLanguage  string `json:"buyerBrowserLanguage,omitempty"`  // e.g. "en"
+}
+
+type OrderTags struct {
+	IfProfitSharing bool `json:"ifProfitSharing"`
 }
 
 func (r *CreateOrderV2Request) EndPoint() string {
 	return "/binancepay/openapi/v2/order"
 }
 
+// Validate checks the documented rules of the fields, failed rules are returned as *ValidationError.
 func (r *CreateOrderV2Request) Validate() error {
-	return validate.Struct(r)
+	return r.ValidateAt(time.Now())
+}
+
+// ValidateAt is Validate checking OrderExpireTime against now, Merchant passes the time of WithClock.
+func (r *CreateOrderV2Request) ValidateAt(now time.Time) error {
+	return validateStructAt(r, now)
+}
+
+// MarshalJSON omits empty Goods, e.g. when the goods are sent as GoodsDetails.
+func (r CreateOrderV2Request) MarshalJSON() ([]byte, error) {
+	type createOrderV2Request CreateOrderV2Request
+	aux := struct {
+		createOrderV2Request
+		Goods *Goods `json:"goods,omitempty"`
+	}{createOrderV2Request: createOrderV2Request(r)}
+	if r.Goods != (Goods{}) {
+		aux.Goods = &r.Goods
+	}
+	return json.Marshal(aux)
+}
+
+// Money returns the OrderAmount in Currency.
+func (r *CreateOrderV2Request) Money() Money {
+	return NewMoney(r.OrderAmount, r.Currency)
 }
 
 type CreateOrderV2Result struct {
@@ -90,3 +183,41 @@ type CreateOrderV2Result struct {
 	Deeplink     string `json:"deeplink"`
 	UniversalUrl string `json:"universalUrl"`
 }
+
+var currencyListRegexp = regexp.MustCompile(`^[A-Z0-9]+(,[A-Z0-9]+)*$`)
+
+// validateGoodsCategory implements the goods_category tag.
+func validateGoodsCategory(fl validator.FieldLevel) bool {
+	return ValidGoodsCategory(fl.Field().String())
+}
+
+// validateFutureMillis implements the future_millis tag for unix milliseconds.
+func validateFutureMillis(ctx context.Context, fl validator.FieldLevel) bool {
+	return time.UnixMilli(fl.Field().Int()).After(validationTime(ctx))
+}
+
+// validateCurrencyList implements the currency_list tag, a comma separated list of upper case currencies.
+func validateCurrencyList(fl validator.FieldLevel) bool {
+	return currencyListRegexp.MatchString(fl.Field().String())
+}
+
+func validateCreateOrderV2Request(sl validator.StructLevel) {
+	r := sl.Current().Interface().(CreateOrderV2Request)
+	if r.Env.TerminalType == TerminalTypeMiniProgram && r.AppId == "" {
+		sl.ReportError(r.AppId, "appId", "AppId", "required_mini_program", "")
+	}
+
+	switch {
+	case r.Goods != (Goods{}):
+		// goods names the namespace of the errors like the JSON field, e.g. goods.goodsName
+		type goods Goods
+		if err := sl.Validator().Struct(goods(r.Goods)); err != nil {
+			var validationErrs validator.ValidationErrors
+			if errors.As(err, &validationErrs) {
+				sl.ReportValidationErrors("", "", validationErrs)
+			}
+		}
+	case len(r.GoodsDetails) == 0:
+		sl.ReportError(r.Goods, "goods", "Goods", "required_goods", "")
+	}
+}
@@ -0,0 +1,198 @@
+package binancepay
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/go-playground/validator/v10"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCreateOrderV2Request_Validate(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(r *CreateOrderV2Request)
+		field  string
+		rule   string
+	}{
+		{"valid", func(r *CreateOrderV2Request) {}, "", ""},
+		{"valid optional fields", func(r *CreateOrderV2Request) {
+			r.Env.TerminalType = "MINI_PROGRAM"
+			r.AppId = "wx123"
+			r.Goods.GoodsQuantity = "3"
+			r.ReturnUrl = "https://example.com/return"
+			r.CancelUrl = "https://example.com/cancel"
+			r.SupportPayCurrency = "BUSD,BNB"
+		}, "", ""},
+		{"long merchantTradeNo", func(r *CreateOrderV2Request) {
+			r.MerchantTradeNo = "123456789012345678901234567890123"
+		}, "merchantTradeNo", "max"},
+		{"merchantTradeNo symbols", func(r *CreateOrderV2Request) { r.MerchantTradeNo = "order-1" }, "merchantTradeNo", "alphanum"},
+		{"mini program without appId", func(r *CreateOrderV2Request) { r.Env.TerminalType = "MINI_PROGRAM" }, "appId", "required_mini_program"},
+		{"unknown goods category", func(r *CreateOrderV2Request) { r.Goods.GoodsCategory = "G000" }, "goods.goodsCategory", "goods_category"},
+		{"goods quantity", func(r *CreateOrderV2Request) { r.Goods.GoodsQuantity = "two" }, "goods.goodsQuantity", "number"},
+		{"expired", func(r *CreateOrderV2Request) {
+			r.OrderExpireTime = testNow.Add(-time.Minute).UnixMilli()
+		}, "orderExpireTime", "future_millis"},
+		{"pay currency list", func(r *CreateOrderV2Request) { r.SupportPayCurrency = "BUSD, bnb" }, "supportPayCurrency", "currency_list"},
+		{"return url", func(r *CreateOrderV2Request) { r.ReturnUrl = "example.com/return" }, "returnUrl", "url"},
+		{"cancel url", func(r *CreateOrderV2Request) { r.CancelUrl = "not a url" }, "cancelUrl", "url"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := newTestOrderRequest()
+			tt.modify(req)
+			err := req.ValidateAt(testNow)
+			if tt.field == "" {
+				assert.Nil(t, err, err)
+				return
+			}
+
+			var validationErr *ValidationError
+			assert.True(t, errors.As(err, &validationErr), err)
+			assert.Len(t, validationErr.Fields, 1)
+			assert.Equal(t, tt.field, validationErr.Fields[0].Field)
+			assert.Equal(t, tt.rule, validationErr.Fields[0].Rule)
+			assert.Contains(t, err.Error(), tt.field+" ")
+
+			// the errors of the validator are still available
+			var validationErrs validator.ValidationErrors
+			assert.True(t, errors.As(err, &validationErrs))
+		})
+	}
+}
+
+func TestValidationError_Fields(t *testing.T) {
+	req := newTestOrderRequest()
+	req.MerchantTradeNo = ""
+	req.Goods.GoodsName = ""
+
+	var validationErr *ValidationError
+	assert.True(t, errors.As(req.ValidateAt(testNow), &validationErr))
+	assert.Equal(t, []FieldError{
+		{Field: "merchantTradeNo", Rule: "required", Value: "", Message: "merchantTradeNo is required"},
+		{Field: "goods.goodsName", Rule: "required", Value: "", Message: "goods.goodsName is required"},
+	}, validationErr.Fields)
+	assert.Equal(t, "invalid request: merchantTradeNo is required; goods.goodsName is required", validationErr.Error())
+}
+
+func newMarketplaceOrderRequest() *CreateOrderV2Request {
+	req := newTestOrderRequest()
+	req.GoodsDetails = []Goods{req.Goods, {
+		GoodsType:        "01",
+		GoodsCategory:    "3000",
+		ReferenceGoodsId: "shoes",
+		GoodsName:        "running shoes",
+		GoodsQuantity:    "2",
+	}}
+	req.Goods = Goods{}
+	req.Shipping = &Shipping{
+		ShippingName: &Name{FirstName: "John", LastName: "Doe"},
+		ShippingAddress: &ShippingAddress{
+			Region:              "SG",
+			City:                "Singapore",
+			Address:             "1 Raffles Place",
+			ZipCode:             "048616",
+			ShippingAddressType: "02",
+		},
+		ShippingPhoneNo: "91234567",
+	}
+	req.Buyer = &Buyer{
+		ReferenceBuyerId:      "buyer1",
+		BuyerName:             &Name{FirstName: "Jane", LastName: "Doe"},
+		BuyerPhoneCountryCode: "65",
+		BuyerPhoneNo:          "98765432",
+		BuyerEmail:            "jane@example.com",
+		BuyerRegistrationTime: 1654943252000,
+	}
+	req.OrderTags = &OrderTags{IfProfitSharing: true}
+	req.WebhookUrl = "https://example.com/webhook"
+	req.PassThroughInfo = `{"cart":"c1"}`
+	return req
+}
+
+func TestCreateOrderV2Request_JSON(t *testing.T) {
+	req := newMarketplaceOrderRequest()
+	assert.Nil(t, req.ValidateAt(testNow))
+
+	data, err := json.Marshal(req)
+	assert.Nil(t, err, err)
+	var shape map[string]interface{}
+	err = json.Unmarshal(data, &shape)
+	assert.Nil(t, err, err)
+
+	// empty goods are omitted in favor of goodsDetails
+	_, ok := shape["goods"]
+	assert.False(t, ok)
+	assert.Len(t, shape["goodsDetails"], 2)
+	assert.Equal(t, map[string]interface{}{
+		"shippingName": map[string]interface{}{"firstName": "John", "lastName": "Doe"},
+		"shippingAddress": map[string]interface{}{
+			"region":              "SG",
+			"city":                "Singapore",
+			"address":             "1 Raffles Place",
+			"zipCode":             "048616",
+			"shippingAddressType": "02",
+		},
+		"shippingPhoneNo": "91234567",
+	}, shape["shipping"])
+	assert.Equal(t, map[string]interface{}{
+		"referenceBuyerId":      "buyer1",
+		"buyerName":             map[string]interface{}{"firstName": "Jane", "lastName": "Doe"},
+		"buyerPhoneCountryCode": "65",
+		"buyerPhoneNo":          "98765432",
+		"buyerEmail":            "jane@example.com",
+		"buyerRegistrationTime": float64(1654943252000),
+	}, shape["buyer"])
+	assert.Equal(t, map[string]interface{}{"ifProfitSharing": true}, shape["orderTags"])
+	assert.Equal(t, "https://example.com/webhook", shape["webhookUrl"])
+	assert.Equal(t, `{"cart":"c1"}`, shape["passThroughInfo"])
+
+	var decoded CreateOrderV2Request
+	err = json.Unmarshal(data, &decoded)
+	assert.Nil(t, err, err)
+	assert.Equal(t, req.Buyer, decoded.Buyer)
+	assert.Equal(t, req.Shipping, decoded.Shipping)
+	assert.Equal(t, req.GoodsDetails, decoded.GoodsDetails)
+
+	// single goods orders keep their shape
+	data, err = json.Marshal(newTestOrderRequest())
+	assert.Nil(t, err, err)
+	assert.Contains(t, string(data), `"goods":{"goodsType":"02","goodsCategory":"Z000"`)
+	assert.NotContains(t, string(data), "goodsDetails")
+	assert.NotContains(t, string(data), "shipping")
+}
+
+func TestCreateOrderV2Request_ValidateMarketplace(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(r *CreateOrderV2Request)
+		field  string
+		rule   string
+	}{
+		{"no goods", func(r *CreateOrderV2Request) { r.GoodsDetails = nil }, "goods", "required_goods"},
+		{"invalid goods detail", func(r *CreateOrderV2Request) { r.GoodsDetails[1].GoodsCategory = "X" }, "goodsDetails[1].goodsCategory", "goods_category"},
+		{"shipping region", func(r *CreateOrderV2Request) { r.Shipping.ShippingAddress.Region = "Singapore" }, "shipping.shippingAddress.region", "iso3166_1_alpha2"},
+		{"shipping address type", func(r *CreateOrderV2Request) { r.Shipping.ShippingAddress.ShippingAddressType = "04" }, "shipping.shippingAddress.shippingAddressType", "oneof"},
+		{"shipping name", func(r *CreateOrderV2Request) { r.Shipping.ShippingName.LastName = "" }, "shipping.shippingName.lastName", "required"},
+		{"buyer name", func(r *CreateOrderV2Request) { r.Buyer.BuyerName = nil }, "buyer.buyerName", "required"},
+		{"buyer email", func(r *CreateOrderV2Request) { r.Buyer.BuyerEmail = "jane" }, "buyer.buyerEmail", "email"},
+		{"buyer phone country code", func(r *CreateOrderV2Request) { r.Buyer.BuyerPhoneCountryCode = "+65" }, "buyer.buyerPhoneCountryCode", "number"},
+		{"webhook url", func(r *CreateOrderV2Request) { r.WebhookUrl = "/webhook" }, "webhookUrl", "url"},
+		{"pass through info", func(r *CreateOrderV2Request) { r.PassThroughInfo = strings.Repeat("x", 513) }, "passThroughInfo", "max"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := newMarketplaceOrderRequest()
+			tt.modify(req)
+
+			var validationErr *ValidationError
+			assert.True(t, errors.As(req.ValidateAt(testNow), &validationErr))
+			assert.Len(t, validationErr.Fields, 1)
+			assert.Equal(t, tt.field, validationErr.Fields[0].Field)
+			assert.Equal(t, tt.rule, validationErr.Fields[0].Rule)
+		})
+	}
+}
@@ -0,0 +1,128 @@
+package binancepay
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+var _ Cache = &FileCache{}
+
+type fileCacheEntry struct {
+	Data      json.RawMessage `json:"data"`
+	ExpiresAt time.Time       `json:"expiresAt,omitempty"`
+}
+
+// FileCache is a Cache persisted as a single JSON file, suitable for single-host deployments
+// where cached certificates should survive restarts. Writes replace the file atomically.
+// It is safe for concurrent use within a process, concurrent writers from several processes may lose updates.
+type FileCache struct {
+	mu   sync.Mutex
+	path string
+	now  func() time.Time
+}
+
+func NewFileCache(path string) *FileCache {
+	return &FileCache{
+		path: path,
+		now:  time.Now,
+	}
+}
+
+func (c *FileCache) GetJSON(ctx context.Context, key string, i interface{}) (ok bool, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entries, err := c.load()
+	if err != nil {
+		return false, err
+	}
+	entry, ok := entries[key]
+	if !ok || (!entry.ExpiresAt.IsZero() && !c.now().Before(entry.ExpiresAt)) {
+		return false, nil
+	}
+	if err = json.Unmarshal(entry.Data, i); err != nil {
+		return false, fmt.Errorf("json.Unmarshal(): %w", err)
+	}
+	return true, nil
+}
+
+// SetJSON stores data for dur, dur <= 0 means the entry never expires. Expired entries are dropped from the file.
+func (c *FileCache) SetJSON(ctx context.Context, key string, data interface{}, dur time.Duration) error {
+	b, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("json.Marshal(): %w", err)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entries, err := c.load()
+	if err != nil {
+		return err
+	}
+
+	now := c.now()
+	for k, entry := range entries {
+		if !entry.ExpiresAt.IsZero() && !now.Before(entry.ExpiresAt) {
+			delete(entries, k)
+		}
+	}
+
+	entry := fileCacheEntry{Data: b}
+	if dur > 0 {
+		entry.ExpiresAt = now.Add(dur)
+	}
+	entries[key] = entry
+
+	return c.store(entries)
+}
+
+func (c *FileCache) load() (map[string]fileCacheEntry, error) {
+	entries := map[string]fileCacheEntry{}
+	b, err := os.ReadFile(c.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return entries, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("os.ReadFile(): %w", err)
+	}
+	if len(b) == 0 {
+		return entries, nil
+	}
+	if err = json.Unmarshal(b, &entries); err != nil {
+		return nil, fmt.Errorf("json.Unmarshal(%s): %w", c.path, err)
+	}
+	return entries, nil
+}
+
+func (c *FileCache) store(entries map[string]fileCacheEntry) error {
+	b, err := json.Marshal(entries)
+	if err != nil {
+		return fmt.Errorf("json.Marshal(): %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(c.path), filepath.Base(c.path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("os.CreateTemp(): %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err = tmp.Write(b); err != nil {
+		tmp.Close()
+		return fmt.Errorf("write(): %w", err)
+	}
+	if err = tmp.Close(); err != nil {
+		return fmt.Errorf("close(): %w", err)
+	}
+	if err = os.Rename(tmp.Name(), c.path); err != nil {
+		return fmt.Errorf("os.Rename(): %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+package binancepay
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestFileCache(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "binancepay-cache.json")
+	now := time.Now()
+	cache := NewFileCache(path)
+	cache.now = func() time.Time { return now }
+
+	var certs []Certificate
+	ok, err := cache.GetJSON(ctx, "certs", &certs)
+	assert.Nil(t, err, err)
+	assert.False(t, ok)
+
+	err = cache.SetJSON(ctx, "certs", []Certificate{{CertSerial: "abc"}}, time.Minute)
+	assert.Nil(t, err, err)
+	err = cache.SetJSON(ctx, "short", 1, time.Second)
+	assert.Nil(t, err, err)
+
+	// a new instance reads what the previous one persisted
+	reopened := NewFileCache(path)
+	reopened.now = cache.now
+	ok, err = reopened.GetJSON(ctx, "certs", &certs)
+	assert.Nil(t, err, err)
+	assert.True(t, ok)
+	assert.Equal(t, "abc", certs[0].CertSerial)
+
+	now = now.Add(2 * time.Second)
+	var v int
+	ok, err = reopened.GetJSON(ctx, "short", &v)
+	assert.Nil(t, err, err)
+	assert.False(t, ok)
+
+	// expired entries are dropped from the file on write
+	err = reopened.SetJSON(ctx, "other", 2, 0)
+	assert.Nil(t, err, err)
+	entries, err := reopened.load()
+	assert.Nil(t, err, err)
+	assert.Len(t, entries, 2)
+	assert.NotContains(t, entries, "short")
+
+	now = now.Add(time.Minute)
+	ok, _ = reopened.GetJSON(ctx, "certs", &certs)
+	assert.False(t, ok)
+	ok, _ = reopened.GetJSON(ctx, "other", &v)
+	assert.True(t, ok)
+	assert.Equal(t, 2, v)
+}
+
+func TestFileCacheCorruptedFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "binancepay-cache.json")
+	assert.Nil(t, os.WriteFile(path, []byte("{not json"), 0o600))
+
+	var v int
+	_, err := NewFileCache(path).GetJSON(context.Background(), "key", &v)
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,126 @@
+package binancepay
+
+import (
+	"encoding/json"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+const testOrderExpireTime int64 = 1655859345000
+
+// testNow is the clock of the tests using testOrderExpireTime.
+var testNow = time.UnixMilli(testOrderExpireTime).Add(-time.Hour)
+
+func testClock() time.Time {
+	return testNow
+}
+
+// newTestOrderRequest returns a valid order request at testNow.
+func newTestOrderRequest() *CreateOrderV2Request {
+	return &CreateOrderV2Request{
+		Env:             Env{TerminalType: "WEB"},
+		MerchantTradeNo: "9825382937292",
+		OrderAmount:     decimal.RequireFromString("0.88"),
+		Currency:        "USDT",
+		Goods: Goods{
+			GoodsType:        "02",
+			GoodsCategory:    "Z000",
+			ReferenceGoodsId: "test_goods_id",
+			GoodsName:        "test goods",
+		},
+		OrderExpireTime: testOrderExpireTime,
+	}
+}
+
+// mockResponse returns a response with status and body.
+func mockResponse(status int, body string) *http.Response {
+	return &http.Response{
+		StatusCode: status,
+		Body:       ioutil.NopCloser(strings.NewReader(body)),
+	}
+}
+
+// mockJSONResponse returns a 200 response with v encoded as body.
+func mockJSONResponse(t *testing.T, v interface{}) *http.Response {
+	body, err := json.Marshal(v)
+	assert.Nil(t, err, err)
+	return mockResponse(http.StatusOK, string(body))
+}
+
+// queryOrderOKHttpClient answers every request with a PAID order and counts the calls.
+func queryOrderOKHttpClient(calls *int) *http.Client {
+	return mockHttpClient(func(request *http.Request) (*http.Response, error) {
+		*calls++
+		return mockResponse(http.StatusOK, `{"status":"SUCCESS","code":"000000","data":{"status":"PAID"}}`), nil
+	})
+}
+
+// flakyHttpClient answers the first failures requests with failure, then succeeds, and records the nonces sent.
+func flakyHttpClient(failures int, failure func() (*http.Response, error), nonces *[]string) *http.Client {
+	calls := 0
+	return mockHttpClient(func(request *http.Request) (*http.Response, error) {
+		calls++
+		*nonces = append(*nonces, request.Header.Get("BinancePay-Nonce"))
+		if calls <= failures {
+			return failure()
+		}
+		return mockResponse(http.StatusOK, `{"status":"SUCCESS","code":"000000","data":{"merchantId":"123"}}`), nil
+	})
+}
+
+// certificatesHttpClient answers the certificates API with certs and counts the fetches.
+func certificatesHttpClient(t *testing.T, fetches *int, certs *[]Certificate) *http.Client {
+	return mockHttpClient(func(request *http.Request) (*http.Response, error) {
+		*fetches++
+		return mockJSONResponse(t, Response[QueryCertificateResult]{Status: "SUCCESS", Code: "000000", Data: *certs}), nil
+	})
+}
+
+// orderEndpointsHttpClient answers the create and query endpoints with the bodies returned by create and query.
+func orderEndpointsHttpClient(create, query func() (int, string, error), paths *[]string) *http.Client {
+	return mockHttpClient(func(request *http.Request) (*http.Response, error) {
+		*paths = append(*paths, request.URL.Path)
+		handle := create
+		if request.URL.Path == "/binancepay/openapi/v2/order/query" {
+			handle = query
+		}
+		status, body, err := handle()
+		if err != nil {
+			return nil, err
+		}
+		return mockResponse(status, body), nil
+	})
+}
+
+// queryOrderHttpClient answers QueryOrderRequest with the status of statuses,
+// unknown orders are answered with CodeOrderNotFound.
+func queryOrderHttpClient(t *testing.T, mu *sync.Mutex, statuses map[string]OrderStatus, queries map[string]int) *http.Client {
+	return mockHttpClient(func(request *http.Request) (*http.Response, error) {
+		assert.Equal(t, "/binancepay/openapi/v2/order/query", request.URL.Path)
+
+		var req QueryOrderRequest
+		err := json.NewDecoder(request.Body).Decode(&req)
+		assert.Nil(t, err, err)
+
+		mu.Lock()
+		queries[req.MerchantTradeNo]++
+		status, ok := statuses[req.MerchantTradeNo]
+		mu.Unlock()
+
+		if !ok {
+			return mockJSONResponse(t, Response[QueryOrderResult]{Status: "FAIL", Code: CodeOrderNotFound, ErrMsg: "order not found"}), nil
+		}
+		return mockJSONResponse(t, Response[QueryOrderResult]{Status: "SUCCESS", Code: CodeSuccess, Data: QueryOrderResult{
+			MerchantTradeNo: req.MerchantTradeNo,
+			Status:          status,
+			Currency:        "USDT",
+			OrderAmount:     "10.5",
+		}}), nil
+	})
+}
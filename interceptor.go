@@ -0,0 +1,47 @@
+package binancepay
+
+import (
+	"context"
+	"go.uber.org/zap"
+	"net/http"
+)
+
+// Call is a single attempt of Merchant.DoContext passing through the interceptors.
+type Call struct {
+	Request IRequest
+	Attempt int
+	Method  string
+	URL     string
+	Body    []byte      // signed request body
+	Header  http.Header // signed request header
+
+	// set by the invoker sending the request
+	StatusCode   int
+	ResponseBody []byte
+	Response     IResponse // decoded from ResponseBody
+
+	logger     *zap.Logger
+	signedBody []byte // Body when Header was signed
+	sent       bool
+	retryable  bool
+}
+
+// Invoker sends a Call.
+type Invoker func(ctx context.Context, call *Call) error
+
+// Interceptor wraps the invoker sending a signed Call, e.g. for logging, metrics, audit trails or fault injection.
+// It may inspect or change the call before and after calling next, or return without calling next.
+// A Body changed before calling next is signed again, headers added to Header are kept.
+// Calling next again resends the call with a fresh nonce and signature.
+type Interceptor func(ctx context.Context, call *Call, next Invoker) error
+
+// chainInterceptors returns an invoker passing calls through interceptors in order, the first one is the outermost.
+func chainInterceptors(interceptors []Interceptor, invoker Invoker) Invoker {
+	for i := len(interceptors) - 1; i >= 0; i-- {
+		interceptor, next := interceptors[i], invoker
+		invoker = func(ctx context.Context, call *Call) error {
+			return interceptor(ctx, call, next)
+		}
+	}
+	return invoker
+}
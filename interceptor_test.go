@@ -0,0 +1,137 @@
+package binancepay
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"net/http"
+	"testing"
+)
+
+func TestMerchant_Interceptors(t *testing.T) {
+	var calls int
+	var order []string
+	audit := func(ctx context.Context, call *Call, next Invoker) error {
+		order = append(order, "audit")
+		req, ok := call.Request.(*QueryOrderRequest)
+		assert.True(t, ok)
+		assert.Equal(t, "abc", req.MerchantTradeNo)
+		assert.Equal(t, `{"merchantTradeNo":"abc"}`, string(call.Body))
+		assert.Equal(t, "POST", call.Method)
+		assert.Equal(t, DefaultHost+"/binancepay/openapi/v2/order/query", call.URL)
+
+		signature, err := Sign([]byte("secret"), []byte(BuildPayload(string(call.Body),
+			call.Header.Get("BinancePay-Timestamp"), call.Header.Get("BinancePay-Nonce"))))
+		assert.Nil(t, err, err)
+		assert.Equal(t, signature, call.Header.Get("BinancePay-Signature"))
+
+		err = next(ctx, call)
+		assert.Nil(t, err, err)
+		assert.Equal(t, http.StatusOK, call.StatusCode)
+		assert.Equal(t, `{"status":"SUCCESS","code":"000000","data":{"status":"PAID"}}`, string(call.ResponseBody))
+		assert.Equal(t, OrderStatusPaid, call.Response.(*Response[QueryOrderResult]).Data.Status)
+		return err
+	}
+	metrics := func(ctx context.Context, call *Call, next Invoker) error {
+		order = append(order, "metrics")
+		return next(ctx, call)
+	}
+
+	m := NewMerchant("key", "secret", nil, logger,
+		WithHTTPClient(queryOrderOKHttpClient(&calls)),
+		WithInterceptors(audit),
+		WithInterceptors(metrics),
+	)
+	var resp Response[QueryOrderResult]
+	err := m.Do(&QueryOrderRequest{MerchantTradeNo: "abc"}, &resp)
+	assert.Nil(t, err, err)
+	assert.Equal(t, OrderStatusPaid, resp.Data.Status)
+	assert.Equal(t, []string{"audit", "metrics"}, order)
+	assert.Equal(t, 1, calls)
+}
+
+func TestMerchant_InterceptorResendsWithFreshNonce(t *testing.T) {
+	var calls int
+	var nonces []string
+	retry := func(ctx context.Context, call *Call, next Invoker) error {
+		for i := 0; i < 2; i++ {
+			if err := next(ctx, call); err != nil {
+				return err
+			}
+			nonces = append(nonces, call.Header.Get("BinancePay-Nonce"))
+		}
+		return nil
+	}
+
+	m := NewMerchant("key", "secret", nil, logger,
+		WithHTTPClient(queryOrderOKHttpClient(&calls)),
+		WithInterceptors(retry),
+	)
+	err := m.Do(&QueryOrderRequest{MerchantTradeNo: "abc"}, &Response[QueryOrderResult]{})
+	assert.Nil(t, err, err)
+	assert.Equal(t, 2, calls)
+	assert.Len(t, nonces, 2)
+	assert.NotEqual(t, nonces[0], nonces[1])
+}
+
+func TestMerchant_InterceptorChangesBody(t *testing.T) {
+	var sent *http.Request
+	var sentBody []byte
+	httpClient := mockHttpClient(func(request *http.Request) (*http.Response, error) {
+		sent = request
+		sentBody, _ = ioutil.ReadAll(request.Body)
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       ioutil.NopCloser(bytes.NewBufferString(`{"status":"SUCCESS","code":"000000","data":{"status":"PAID"}}`)),
+		}, nil
+	})
+	rewrite := func(ctx context.Context, call *Call, next Invoker) error {
+		call.Body = []byte(`{"merchantTradeNo":"def"}`)
+		call.Header.Set("X-Request-Id", "r1")
+		return next(ctx, call)
+	}
+
+	m := NewMerchant("key", "secret", nil, logger,
+		WithHTTPClient(httpClient),
+		WithInterceptors(rewrite),
+	)
+	err := m.Do(&QueryOrderRequest{MerchantTradeNo: "abc"}, &Response[QueryOrderResult]{})
+	assert.Nil(t, err, err)
+	assert.Equal(t, `{"merchantTradeNo":"def"}`, string(sentBody))
+	assert.Equal(t, "r1", sent.Header.Get("X-Request-Id"))
+
+	signature, err := Sign([]byte("secret"), []byte(BuildPayload(string(sentBody),
+		sent.Header.Get("BinancePay-Timestamp"), sent.Header.Get("BinancePay-Nonce"))))
+	assert.Nil(t, err, err)
+	assert.Equal(t, signature, sent.Header.Get("BinancePay-Signature"))
+}
+
+func TestMerchant_InterceptorFaultInjection(t *testing.T) {
+	var calls int
+	fault := func(ctx context.Context, call *Call, next Invoker) error {
+		if call.Attempt == 1 {
+			return ErrUnknownError
+		}
+		return next(ctx, call)
+	}
+
+	m := NewMerchant("key", "secret", nil, logger,
+		WithHTTPClient(queryOrderOKHttpClient(&calls)),
+		WithInterceptors(fault),
+	)
+	err := m.Do(&QueryOrderRequest{MerchantTradeNo: "abc"}, &Response[QueryOrderResult]{})
+	assert.True(t, errors.Is(err, ErrUnknownError))
+	assert.Equal(t, 0, calls)
+
+	// injected errors are retried like responses with the same code
+	m = NewMerchant("key", "secret", nil, logger,
+		WithHTTPClient(queryOrderOKHttpClient(&calls)),
+		WithInterceptors(fault),
+		WithRetryPolicy(testRetryPolicy),
+	)
+	err = m.Do(&QueryOrderRequest{MerchantTradeNo: "abc"}, &Response[QueryOrderResult]{})
+	assert.Nil(t, err, err)
+	assert.Equal(t, 1, calls)
+}
@@ -0,0 +1,105 @@
+package binancepay
+
+import (
+	"context"
+	"fmt"
+	"go.uber.org/zap"
+	"sync"
+	"time"
+)
+
+// DefaultLayeredCacheLocalTTL is the localTTL of a LayeredCache created with a localTTL <= 0.
+const DefaultLayeredCacheLocalTTL = time.Minute
+
+var _ Cache = &LayeredCache{}
+
+// LayeredCache reads through a fast local Cache to a shared remote Cache, e.g. a MemoryCache in front of redis.
+// Items found in the remote layer are copied into the local layer for at most localTTL, so changes of
+// the remote layer reach the local one after localTTL. Errors of the local layer fall through to the remote layer.
+type LayeredCache struct {
+	local    Cache
+	remote   Cache
+	localTTL time.Duration
+	logger   *zap.Logger
+
+	mu    sync.Mutex
+	stale map[string]time.Time // keys whose local item may be outdated, until the item expires
+}
+
+// NewLayeredCache uses DefaultLayeredCacheLocalTTL when localTTL <= 0, logger may be nil.
+func NewLayeredCache(local, remote Cache, localTTL time.Duration, logger *zap.Logger) *LayeredCache {
+	if localTTL <= 0 {
+		localTTL = DefaultLayeredCacheLocalTTL
+	}
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	return &LayeredCache{
+		local:    local,
+		remote:   remote,
+		localTTL: localTTL,
+		logger:   logger,
+		stale:    map[string]time.Time{},
+	}
+}
+
+func (c *LayeredCache) GetJSON(ctx context.Context, key string, i interface{}) (ok bool, err error) {
+	if !c.isStale(key) {
+		ok, err = c.local.GetJSON(ctx, key, i)
+		if err == nil && ok {
+			return true, nil
+		}
+	}
+
+	ok, err = c.remote.GetJSON(ctx, key, i)
+	if err != nil {
+		return false, fmt.Errorf("remote.GetJSON(): %w", err)
+	}
+	if !ok {
+		return false, nil
+	}
+
+	if err = c.local.SetJSON(ctx, key, i, c.localTTL); err != nil {
+		c.logger.Warn("failed to copy item into local cache", zap.String("key", key), zap.Error(err))
+	}
+	return true, nil
+}
+
+// SetJSON writes data to the remote layer first, then to the local layer for at most localTTL.
+// A failed local write is logged, the local layer is bypassed for the key until its old item expired.
+func (c *LayeredCache) SetJSON(ctx context.Context, key string, data interface{}, dur time.Duration) error {
+	if err := c.remote.SetJSON(ctx, key, data, dur); err != nil {
+		return fmt.Errorf("remote.SetJSON(): %w", err)
+	}
+
+	localDur := c.localTTL
+	if dur > 0 && dur < localDur {
+		localDur = dur
+	}
+	if err := c.local.SetJSON(ctx, key, data, localDur); err != nil {
+		c.logger.Warn("failed to write item into local cache", zap.String("key", key), zap.Error(err))
+		c.markStale(key)
+		return nil
+	}
+	c.mu.Lock()
+	delete(c.stale, key)
+	c.mu.Unlock()
+	return nil
+}
+
+func (c *LayeredCache) markStale(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.stale[key] = time.Now().Add(c.localTTL)
+}
+
+func (c *LayeredCache) isStale(key string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	until, ok := c.stale[key]
+	if ok && !time.Now().Before(until) {
+		delete(c.stale, key)
+		return false
+	}
+	return ok
+}
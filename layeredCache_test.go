@@ -0,0 +1,132 @@
+package binancepay
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestLayeredCache(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	local := NewMemoryCache(0)
+	local.now = func() time.Time { return now }
+	remote := NewMemoryCache(0)
+	remote.now = local.now
+	cache := NewLayeredCache(local, remote, time.Minute, logger)
+
+	// read through to the remote layer and populate the local one
+	_ = remote.SetJSON(ctx, "certs", []Certificate{{CertSerial: "abc"}}, time.Hour)
+	var certs []Certificate
+	ok, err := cache.GetJSON(ctx, "certs", &certs)
+	assert.Nil(t, err, err)
+	assert.True(t, ok)
+	assert.Equal(t, "abc", certs[0].CertSerial)
+	assert.Equal(t, 1, local.Len())
+
+	// the local copy serves reads until localTTL passes
+	_ = remote.SetJSON(ctx, "certs", []Certificate{{CertSerial: "def"}}, time.Hour)
+	_, _ = cache.GetJSON(ctx, "certs", &certs)
+	assert.Equal(t, "abc", certs[0].CertSerial)
+
+	now = now.Add(time.Minute)
+	_, _ = cache.GetJSON(ctx, "certs", &certs)
+	assert.Equal(t, "def", certs[0].CertSerial)
+
+	// writes go to both layers, the local one never outlives dur
+	err = cache.SetJSON(ctx, "nonce", true, time.Second)
+	assert.Nil(t, err, err)
+	var seen bool
+	ok, _ = local.GetJSON(ctx, "nonce", &seen)
+	assert.True(t, ok)
+	ok, _ = remote.GetJSON(ctx, "nonce", &seen)
+	assert.True(t, ok)
+
+	now = now.Add(2 * time.Second)
+	ok, _ = cache.GetJSON(ctx, "nonce", &seen)
+	assert.False(t, ok)
+
+	ok, _ = cache.GetJSON(ctx, "missing", &seen)
+	assert.False(t, ok)
+}
+
+type failingSetCache struct {
+	Cache
+}
+
+func (c failingSetCache) SetJSON(ctx context.Context, key string, data interface{}, dur time.Duration) error {
+	return errors.New("local cache full")
+}
+
+func TestLayeredCache_LocalErrors(t *testing.T) {
+	ctx := context.Background()
+	remote := NewMemoryCache(0)
+	_ = remote.SetJSON(ctx, "certs", []Certificate{{CertSerial: "abc"}}, time.Hour)
+
+	// a failed copy into the local layer doesn't fail the read
+	cache := NewLayeredCache(failingSetCache{NewMemoryCache(0)}, remote, time.Minute, logger)
+	var certs []Certificate
+	ok, err := cache.GetJSON(ctx, "certs", &certs)
+	assert.Nil(t, err, err)
+	assert.True(t, ok)
+	assert.Equal(t, "abc", certs[0].CertSerial)
+}
+
+// toggleSetCache fails SetJSON while fail is set.
+type toggleSetCache struct {
+	Cache
+	fail bool
+}
+
+func (c *toggleSetCache) SetJSON(ctx context.Context, key string, data interface{}, dur time.Duration) error {
+	if c.fail {
+		return errors.New("local cache unavailable")
+	}
+	return c.Cache.SetJSON(ctx, key, data, dur)
+}
+
+func TestLayeredCache_LocalSetError(t *testing.T) {
+	ctx := context.Background()
+	local := &toggleSetCache{Cache: NewMemoryCache(0)}
+	cache := NewLayeredCache(local, NewMemoryCache(0), time.Minute, logger)
+	assert.Nil(t, cache.SetJSON(ctx, "nonce", true, time.Hour))
+
+	// the remote write succeeded, the outdated local item must not be read
+	local.fail = true
+	assert.Nil(t, cache.SetJSON(ctx, "nonce", false, time.Hour))
+	var seen bool
+	ok, err := cache.GetJSON(ctx, "nonce", &seen)
+	assert.Nil(t, err, err)
+	assert.True(t, ok)
+	assert.False(t, seen)
+
+	// a successful local write makes the local layer usable again
+	local.fail = false
+	assert.Nil(t, cache.SetJSON(ctx, "nonce", true, time.Hour))
+	assert.False(t, cache.isStale("nonce"))
+	ok, err = local.GetJSON(ctx, "nonce", &seen)
+	assert.Nil(t, err, err)
+	assert.True(t, ok && seen)
+}
+
+func TestLayeredCache_DefaultLocalTTL(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	local := NewMemoryCache(0)
+	local.now = func() time.Time { return now }
+	remote := NewMemoryCache(0)
+	remote.now = local.now
+	cache := NewLayeredCache(local, remote, 0, nil)
+
+	_ = remote.SetJSON(ctx, "certs", []Certificate{{CertSerial: "abc"}}, time.Hour)
+	var certs []Certificate
+	_, _ = cache.GetJSON(ctx, "certs", &certs)
+	_ = remote.SetJSON(ctx, "certs", []Certificate{{CertSerial: "def"}}, time.Hour)
+
+	// the local copy expires, so the remote change is seen
+	now = now.Add(DefaultLayeredCacheLocalTTL)
+	_, _ = cache.GetJSON(ctx, "certs", &certs)
+	assert.Equal(t, "def", certs[0].CertSerial)
+}
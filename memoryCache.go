@@ -0,0 +1,136 @@
+package binancepay
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+)
+
+var _ Cache = &MemoryCache{}
+
+type memoryCacheItem struct {
+	data      []byte
+	expiresAt time.Time // zero for items which never expire
+}
+
+func (i memoryCacheItem) expired(now time.Time) bool {
+	return !i.expiresAt.IsZero() && !now.Before(i.expiresAt)
+}
+
+// MemoryCache is a concurrency-safe in-process Cache.
+// Values are stored JSON encoded, so GetJSON always returns a copy.
+type MemoryCache struct {
+	mu         sync.Mutex
+	items      map[string]memoryCacheItem
+	maxEntries int
+	now        func() time.Time
+}
+
+// NewMemoryCache returns a MemoryCache holding at most maxEntries items, 0 means unlimited.
+// When full, expired items are dropped first, then the items closest to expiry.
+func NewMemoryCache(maxEntries int) *MemoryCache {
+	return &MemoryCache{
+		items:      map[string]memoryCacheItem{},
+		maxEntries: maxEntries,
+		now:        time.Now,
+	}
+}
+
+func (c *MemoryCache) GetJSON(ctx context.Context, key string, i interface{}) (ok bool, err error) {
+	c.mu.Lock()
+	item, ok := c.items[key]
+	if ok && item.expired(c.now()) {
+		delete(c.items, key)
+		ok = false
+	}
+	c.mu.Unlock()
+
+	if !ok {
+		return false, nil
+	}
+	if err = json.Unmarshal(item.data, i); err != nil {
+		return false, fmt.Errorf("json.Unmarshal(): %w", err)
+	}
+	return true, nil
+}
+
+// SetJSON stores data for dur, dur <= 0 means the item never expires.
+func (c *MemoryCache) SetJSON(ctx context.Context, key string, data interface{}, dur time.Duration) error {
+	b, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("json.Marshal(): %w", err)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.now()
+	item := memoryCacheItem{data: b}
+	if dur > 0 {
+		item.expiresAt = now.Add(dur)
+	}
+	if _, exists := c.items[key]; !exists && c.maxEntries > 0 && len(c.items) >= c.maxEntries {
+		c.evict(now)
+	}
+	c.items[key] = item
+	return nil
+}
+
+// Delete removes the item stored under key.
+func (c *MemoryCache) Delete(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.items, key)
+}
+
+// Len returns the number of stored items, including expired items not yet removed.
+func (c *MemoryCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.items)
+}
+
+// DeleteExpired removes all expired items.
+func (c *MemoryCache) DeleteExpired() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.deleteExpired(c.now())
+}
+
+func (c *MemoryCache) deleteExpired(now time.Time) (deleted int) {
+	for key, item := range c.items {
+		if item.expired(now) {
+			delete(c.items, key)
+			deleted++
+		}
+	}
+	return deleted
+}
+
+// evict makes room for one item.
+func (c *MemoryCache) evict(now time.Time) {
+	if c.deleteExpired(now) > 0 {
+		return
+	}
+
+	var victim string
+	var victimItem memoryCacheItem
+	found := false
+	for key, item := range c.items {
+		if !found || expiresBefore(item, victimItem) {
+			victim, victimItem, found = key, item, true
+		}
+	}
+	if found {
+		delete(c.items, victim)
+	}
+}
+
+func expiresBefore(a, b memoryCacheItem) bool {
+	if a.expiresAt.IsZero() {
+		return false
+	}
+	return b.expiresAt.IsZero() || a.expiresAt.Before(b.expiresAt)
+}
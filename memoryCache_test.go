@@ -0,0 +1,94 @@
+package binancepay
+
+import (
+	"context"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestMemoryCacheExpiry(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	cache := NewMemoryCache(0)
+	cache.now = func() time.Time { return now }
+
+	err := cache.SetJSON(ctx, "certs", []Certificate{{CertSerial: "abc"}}, time.Minute)
+	assert.Nil(t, err, err)
+	err = cache.SetJSON(ctx, "forever", "value", 0)
+	assert.Nil(t, err, err)
+
+	var certs []Certificate
+	ok, err := cache.GetJSON(ctx, "certs", &certs)
+	assert.Nil(t, err, err)
+	assert.True(t, ok)
+	assert.Equal(t, "abc", certs[0].CertSerial)
+
+	now = now.Add(time.Minute)
+	ok, err = cache.GetJSON(ctx, "certs", &certs)
+	assert.Nil(t, err, err)
+	assert.False(t, ok)
+	assert.Equal(t, 1, cache.Len())
+
+	var value string
+	ok, _ = cache.GetJSON(ctx, "forever", &value)
+	assert.True(t, ok)
+	assert.Equal(t, "value", value)
+
+	ok, _ = cache.GetJSON(ctx, "missing", &value)
+	assert.False(t, ok)
+}
+
+func TestMemoryCacheEviction(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	cache := NewMemoryCache(2)
+	cache.now = func() time.Time { return now }
+
+	_ = cache.SetJSON(ctx, "a", 1, time.Hour)
+	_ = cache.SetJSON(ctx, "b", 2, time.Minute)
+	_ = cache.SetJSON(ctx, "c", 3, time.Hour)
+	assert.Equal(t, 2, cache.Len())
+
+	var v int
+	ok, _ := cache.GetJSON(ctx, "b", &v)
+	assert.False(t, ok, "the item closest to expiry is evicted")
+	ok, _ = cache.GetJSON(ctx, "a", &v)
+	assert.True(t, ok)
+
+	// expired items are dropped before live ones
+	now = now.Add(2 * time.Hour)
+	_ = cache.SetJSON(ctx, "d", 4, 0)
+	_ = cache.SetJSON(ctx, "e", 5, time.Hour)
+	assert.Equal(t, 2, cache.Len())
+	ok, _ = cache.GetJSON(ctx, "d", &v)
+	assert.True(t, ok)
+
+	cache.Delete("d")
+	assert.Equal(t, 1, cache.Len())
+
+	now = now.Add(2 * time.Hour)
+	cache.DeleteExpired()
+	assert.Equal(t, 0, cache.Len())
+}
+
+func TestMemoryCacheConcurrency(t *testing.T) {
+	ctx := context.Background()
+	cache := NewMemoryCache(10)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			key := fmt.Sprintf("key%d", i%15)
+			_ = cache.SetJSON(ctx, key, i, time.Minute)
+			var v int
+			_, _ = cache.GetJSON(ctx, key, &v)
+		}(i)
+	}
+	wg.Wait()
+	assert.LessOrEqual(t, cache.Len(), 10)
+}
@@ -0,0 +1,188 @@
+package binancepay
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/go-playground/validator/v10"
+	"github.com/shopspring/decimal"
+	"reflect"
+)
+
+// DefaultPrecision is the number of decimal places of crypto currencies, see PrecisionOf.
+const DefaultPrecision int32 = 8
+
+// currencyPrecision is the number of decimal places accepted per fiat currency.
+var currencyPrecision = map[string]int32{
+	"USD": 2,
+	"EUR": 2,
+	"GBP": 2,
+}
+
+// order amounts accepted by binance pay
+var (
+	MinOrderAmount = decimal.New(1, -2)
+	MaxOrderAmount = decimal.NewFromInt(20000)
+)
+
+var (
+	// ErrAmountPrecision is returned for amounts with more decimal places than their currency accepts.
+	ErrAmountPrecision = errors.New("amount exceeds currency precision")
+	// ErrAmountOutOfRange is returned for order amounts outside of MinOrderAmount and MaxOrderAmount.
+	ErrAmountOutOfRange = errors.New("order amount out of range")
+)
+
+// PrecisionOf returns the number of decimal places accepted for currency, DefaultPrecision for crypto currencies.
+func PrecisionOf(currency string) int32 {
+	if precision, ok := currencyPrecision[currency]; ok {
+		return precision
+	}
+	return DefaultPrecision
+}
+
+// Money is an amount of a currency.
+//
+// Binance pay sends amounts both as JSON strings and as JSON numbers, both are decoded, empty strings and null
+// as zero. Amounts are encoded as JSON strings to keep their precision.
+type Money struct {
+	Amount   decimal.Decimal `json:"amount" validate:"required,precision=Currency"`
+	Currency string          `json:"currency" validate:"required"`
+}
+
+// Amount is the former name of Money.
+type Amount = Money
+
+func NewMoney(amount decimal.Decimal, currency string) Money {
+	return Money{Amount: amount, Currency: currency}
+}
+
+// ParseMoney parses amount, e.g. "10.5", in currency.
+func ParseMoney(amount, currency string) (Money, error) {
+	d, err := decimal.NewFromString(amount)
+	if err != nil {
+		return Money{}, fmt.Errorf("decimal.NewFromString(%q): %w", amount, err)
+	}
+	return Money{Amount: d, Currency: currency}, nil
+}
+
+func (m Money) String() string {
+	return m.Amount.String() + " " + m.Currency
+}
+
+func (m Money) IsZero() bool {
+	return m.Amount.IsZero()
+}
+
+// Equal reports whether m and o are the same amount of the same currency, regardless of trailing zeros.
+func (m Money) Equal(o Money) bool {
+	return m.Currency == o.Currency && m.Amount.Equal(o.Amount)
+}
+
+// Round rounds the amount half away from zero to the precision of the currency.
+func (m Money) Round() Money {
+	return Money{Amount: m.Amount.Round(PrecisionOf(m.Currency)), Currency: m.Currency}
+}
+
+// Validate checks the amount has no more decimal places than its currency accepts.
+func (m Money) Validate() error {
+	if !m.Amount.Equal(m.Amount.Truncate(PrecisionOf(m.Currency))) {
+		return fmt.Errorf("%s: %w", m, ErrAmountPrecision)
+	}
+	return nil
+}
+
+// ValidateOrderAmount checks m is a valid amount of an order.
+func (m Money) ValidateOrderAmount() error {
+	if err := m.Validate(); err != nil {
+		return err
+	}
+	if m.Amount.LessThan(MinOrderAmount) || m.Amount.GreaterThan(MaxOrderAmount) {
+		return fmt.Errorf("%s: %w", m, ErrAmountOutOfRange)
+	}
+	return nil
+}
+
+func (m *Money) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		Amount   json.RawMessage `json:"amount"`
+		Currency string          `json:"currency"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	amount, err := unmarshalAmount(raw.Amount)
+	if err != nil {
+		return fmt.Errorf("unmarshalAmount(): %w", err)
+	}
+	*m = Money{Amount: amount, Currency: raw.Currency}
+	return nil
+}
+
+// moneyOf parses amount as sent by binance pay in currency, an empty amount is zero.
+func moneyOf(amount, currency string) (Money, error) {
+	if amount == "" {
+		return NewMoney(decimal.Zero, currency), nil
+	}
+	return ParseMoney(amount, currency)
+}
+
+// unmarshalAmount decodes an amount sent as JSON string or number, empty strings and null are zero.
+func unmarshalAmount(data []byte) (decimal.Decimal, error) {
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 || string(data) == "null" || string(data) == `""` {
+		return decimal.Zero, nil
+	}
+	var d decimal.Decimal
+	if err := d.UnmarshalJSON(data); err != nil {
+		return decimal.Zero, err
+	}
+	return d, nil
+}
+
+// validateDecimal makes validator tags like required and gte work on decimal.Decimal fields.
+func validateDecimal(field reflect.Value) interface{} {
+	if d, ok := field.Interface().(decimal.Decimal); ok {
+		return d.InexactFloat64()
+	}
+	return nil
+}
+
+// fieldDecimal returns the decimal.Decimal validated by fl, which validateDecimal turned into a float64.
+func fieldDecimal(fl validator.FieldLevel) decimal.Decimal {
+	parent := fl.Parent()
+	for parent.Kind() == reflect.Ptr {
+		parent = parent.Elem()
+	}
+	if parent.Kind() == reflect.Struct {
+		if d, ok := parent.FieldByName(fl.StructFieldName()).Interface().(decimal.Decimal); ok {
+			return d
+		}
+	}
+	if fl.Field().CanFloat() {
+		return decimal.NewFromFloat(fl.Field().Float())
+	}
+	return decimal.Zero
+}
+
+// validatePrecision implements the precision tag, its param is the name of the currency field,
+// e.g. `validate:"precision=Currency"`.
+func validatePrecision(fl validator.FieldLevel) bool {
+	var currency string
+	if param := fl.Param(); param != "" {
+		parent := fl.Parent()
+		for parent.Kind() == reflect.Ptr {
+			parent = parent.Elem()
+		}
+		if field := parent.FieldByName(param); field.IsValid() && field.Kind() == reflect.String {
+			currency = field.String()
+		}
+	}
+	return NewMoney(fieldDecimal(fl), currency).Validate() == nil
+}
+
+// validateOrderAmount implements the order_amount tag.
+func validateOrderAmount(fl validator.FieldLevel) bool {
+	amount := fieldDecimal(fl)
+	return !amount.LessThan(MinOrderAmount) && !amount.GreaterThan(MaxOrderAmount)
+}
@@ -0,0 +1,133 @@
+package binancepay
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/go-playground/validator/v10"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestMoney_Validate(t *testing.T) {
+	assert.Nil(t, NewMoney(decimal.RequireFromString("0.12345678"), "USDT").Validate())
+	assert.ErrorIs(t, NewMoney(decimal.RequireFromString("0.123456789"), "USDT").Validate(), ErrAmountPrecision)
+	assert.Nil(t, NewMoney(decimal.RequireFromString("10.50"), "USD").Validate())
+	assert.ErrorIs(t, NewMoney(decimal.RequireFromString("10.505"), "USD").Validate(), ErrAmountPrecision)
+
+	assert.Equal(t, "10.51 USD", NewMoney(decimal.RequireFromString("10.505"), "USD").Round().String())
+	assert.True(t, NewMoney(decimal.RequireFromString("10.50"), "USD").Equal(NewMoney(decimal.RequireFromString("10.5"), "USD")))
+	assert.False(t, NewMoney(decimal.RequireFromString("10.5"), "USD").Equal(NewMoney(decimal.RequireFromString("10.5"), "EUR")))
+
+	for amount, err := range map[string]error{
+		"0.01":      nil,
+		"20000":     nil,
+		"0.009":     ErrAmountOutOfRange,
+		"20000.01":  ErrAmountOutOfRange,
+		"0.0000001": ErrAmountOutOfRange,
+	} {
+		money, parseErr := ParseMoney(amount, "USDT")
+		assert.Nil(t, parseErr, parseErr)
+		assert.ErrorIs(t, money.ValidateOrderAmount(), err, amount)
+	}
+}
+
+func TestCreateOrderV2Request_ValidateAmount(t *testing.T) {
+	for amount, field := range map[string]string{
+		"0.88":        "",
+		"0":           "orderAmount",
+		"0.001":       "orderAmount",
+		"20001":       "orderAmount",
+		"1.123456789": "orderAmount",
+	} {
+		req := newTestOrderRequest()
+		req.OrderAmount = decimal.RequireFromString(amount)
+		err := req.ValidateAt(testNow)
+		if field == "" {
+			assert.Nil(t, err, err)
+			continue
+		}
+		var validationErrs validator.ValidationErrors
+		assert.True(t, errors.As(err, &validationErrs), amount)
+		assert.Equal(t, field, validationErrs[0].Field(), amount)
+	}
+
+	req := newTestOrderRequest()
+	req.Goods.GoodsUnitAmount = &Money{Amount: decimal.RequireFromString("1.001"), Currency: "USD"}
+	assert.NotNil(t, req.ValidateAt(testNow))
+	req.Goods.GoodsUnitAmount.Amount = decimal.RequireFromString("1.01")
+	assert.Nil(t, req.ValidateAt(testNow))
+}
+
+func TestMoney_JSON(t *testing.T) {
+	for data, amount := range map[string]string{
+		`{"amount":"10.50","currency":"USDT"}`: "10.5",
+		`{"amount":10.50,"currency":"USDT"}`:   "10.5",
+		`{"amount":"","currency":"USDT"}`:      "0",
+		`{"amount":null,"currency":"USDT"}`:    "0",
+		`{"currency":"USDT"}`:                  "0",
+	} {
+		var money Money
+		err := json.Unmarshal([]byte(data), &money)
+		assert.Nil(t, err, err)
+		assert.Equal(t, amount+" USDT", money.String(), data)
+	}
+
+	var money Money
+	assert.NotNil(t, json.Unmarshal([]byte(`{"amount":"ten","currency":"USDT"}`), &money))
+
+	data, err := json.Marshal(NewMoney(decimal.RequireFromString("0.10000001"), "USDT"))
+	assert.Nil(t, err, err)
+	assert.Equal(t, `{"amount":"0.10000001","currency":"USDT"}`, string(data))
+}
+
+func TestNotiMoney(t *testing.T) {
+	var payout PayoutNoti
+	err := json.Unmarshal([]byte(`{"requestId":"r1","currency":"USDT","totalAmount":"100.25","totalNumber":"2"}`), &payout)
+	assert.Nil(t, err, err)
+	money, err := payout.Money()
+	assert.Nil(t, err, err)
+	assert.Equal(t, "100.25 USDT", money.String())
+
+	refund := RefundOrderNoti{TotalFee: "0.88000000", Currency: "USDT"}
+	money, err = refund.Money()
+	assert.Nil(t, err, err)
+	assert.Equal(t, "0.88 USDT", money.String())
+
+	order := QueryOrderResult{Currency: "BUSD", OrderAmount: "25.17000000"}
+	money, err = order.Money()
+	assert.Nil(t, err, err)
+	assert.True(t, money.Equal(NewMoney(decimal.RequireFromString("25.17"), "BUSD")))
+
+	// empty amounts are zero
+	order = QueryOrderResult{Currency: "USDT"}
+	money, err = order.Money()
+	assert.Nil(t, err, err)
+	assert.True(t, money.IsZero())
+	assert.Equal(t, "USDT", money.Currency)
+
+	order.OrderAmount = "ten"
+	_, err = order.Money()
+	assert.NotNil(t, err)
+}
+
+func TestBatchPayoutRequest_ValidateTransferPrecision(t *testing.T) {
+	req := &BatchPayoutRequest{
+		RequestId:   "r1",
+		BatchName:   "rewards",
+		Currency:    "USD",
+		TotalAmount: decimal.RequireFromString("1.5"),
+		TotalNumber: 2,
+		TransferDetailList: []TransferDetail{
+			{MerchantSendId: "s1", ReceiveType: PayoutReceiveTypeEmail, Receiver: "a@example.com", TransferAmount: decimal.RequireFromString("0.5")},
+			{MerchantSendId: "s2", ReceiveType: PayoutReceiveTypeEmail, Receiver: "b@example.com", TransferAmount: decimal.RequireFromString("1")},
+		},
+	}
+	assert.Nil(t, req.Validate())
+
+	req.TransferDetailList[1].TransferAmount = decimal.RequireFromString("0.995")
+	var validationErr *ValidationError
+	assert.True(t, errors.As(req.Validate(), &validationErr))
+	assert.Equal(t, "transferDetailList[1].transferAmount", validationErr.Fields[0].Field)
+	assert.Equal(t, "precision", validationErr.Fields[0].Rule)
+}
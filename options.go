@@ -0,0 +1,158 @@
+package binancepay
+
+import (
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"net/http"
+	"time"
+)
+
+// Option configures a Merchant created by NewMerchant.
+type Option func(m *Merchant)
+
+// WithHost overrides DefaultHost, e.g. to point the client at a local stand-in of the binance pay API.
+func WithHost(host string) Option {
+	return func(m *Merchant) {
+		m.host = host
+	}
+}
+
+// WithHTTPClient overrides http.DefaultClient, e.g. to configure timeouts or a custom transport.
+// A nil client keeps http.DefaultClient.
+func WithHTTPClient(client *http.Client) Option {
+	return func(m *Merchant) {
+		if client != nil {
+			m.httpClient = client
+		}
+	}
+}
+
+// WithClock overrides time.Now used for request timestamps.
+func WithClock(now func() time.Time) Option {
+	return func(m *Merchant) {
+		m.now = now
+	}
+}
+
+// WithNonceFunc overrides Nonce used to generate request nonces.
+func WithNonceFunc(nonce func() string) Option {
+	return func(m *Merchant) {
+		m.nonce = nonce
+	}
+}
+
+// WithLogger overrides the logger passed to NewMerchant.
+func WithLogger(logger *zap.Logger) Option {
+	return func(m *Merchant) {
+		m.logger = logger
+	}
+}
+
+// WithRetryPolicy enables retries of transient failures, see RetryPolicy.
+func WithRetryPolicy(policy RetryPolicy) Option {
+	return func(m *Merchant) {
+		m.retryPolicy = policy
+	}
+}
+
+// WithWebhookWindow rejects webhooks whose Binancepay-Timestamp differs from the clock by more than window
+// with ErrWebhookExpired. It is disabled by default.
+func WithWebhookWindow(window time.Duration) Option {
+	return func(m *Merchant) {
+		m.webhookWindow = window
+	}
+}
+
+// WithWebhookNonceStore rejects webhooks whose Binancepay-Nonce was already seen, with ErrWebhookInProgress
+// until the first webhook was processed and with ErrWebhookReplayed after. The nonce of a processed webhook
+// must be completed, see CompleteWebhookNonce, the nonce of a webhook which fails to be processed must be released,
+// see ReleaseWebhookNonce.
+// It is disabled by default.
+func WithWebhookNonceStore(store NonceStore) Option {
+	return func(m *Merchant) {
+		m.nonceStore = store
+	}
+}
+
+// WithCertRefreshInterval overrides DefaultCertRefreshInterval.
+func WithCertRefreshInterval(interval time.Duration) Option {
+	return func(m *Merchant) {
+		m.certRefreshInterval = interval
+	}
+}
+
+// WithCertificates preloads the certificates used to verify webhooks, e.g. to verify webhooks offline.
+// Unknown serials and failed verifications still refetch the certificates from the API.
+// Certificates which fail to parse are logged and skipped.
+func WithCertificates(certs ...Certificate) Option {
+	return func(m *Merchant) {
+		m.preloadedCerts = certs
+	}
+}
+
+// WithRateLimit limits the requests of the merchant to all endpoints, see RateLimit.
+// Requests wait for the limiter, or fail with the error of their context.
+// Throttled requests, see IsRateLimited, back off and are resent, even without a RetryPolicy.
+func WithRateLimit(limit RateLimit) Option {
+	return func(m *Merchant) {
+		if m.limiter == nil {
+			m.limiter = &rateLimiter{endpoints: map[string]*tokenBucket{}}
+		}
+		m.limiter.global = newTokenBucket(limit)
+	}
+}
+
+// WithEndpointRateLimit limits the requests to endpoint, e.g. "/binancepay/openapi/v2/order",
+// on top of the limit of WithRateLimit.
+func WithEndpointRateLimit(endpoint string, limit RateLimit) Option {
+	return func(m *Merchant) {
+		if m.limiter == nil {
+			m.limiter = &rateLimiter{endpoints: map[string]*tokenBucket{}}
+		}
+		m.limiter.endpoints[endpoint] = newTokenBucket(limit)
+	}
+}
+
+// WithInterceptors passes every attempt of Merchant.DoContext through interceptors, the first one is the outermost.
+func WithInterceptors(interceptors ...Interceptor) Option {
+	return func(m *Merchant) {
+		m.interceptors = append(m.interceptors, interceptors...)
+	}
+}
+
+// WithTracerProvider traces requests, webhook verifications and certificate fetches. Nothing is traced by default.
+func WithTracerProvider(tp trace.TracerProvider) Option {
+	return func(m *Merchant) {
+		m.tracerProvider = tp
+	}
+}
+
+// WithMeterProvider records the MetricRequestDuration and MetricRequestErrors metrics. Nothing is recorded by default.
+func WithMeterProvider(mp metric.MeterProvider) Option {
+	return func(m *Merchant) {
+		m.meterProvider = mp
+	}
+}
+
+// WithRedactedFields masks the given JSON fields of logged bodies on top of DefaultRedactedFields.
+// Field names are matched case-insensitively at any depth.
+func WithRedactedFields(fields ...string) Option {
+	return func(m *Merchant) {
+		m.redactedFields = append(m.redactedFields, fields...)
+	}
+}
+
+// WithMetadataOnlyLogs logs the size of request, response and webhook bodies instead of their content.
+func WithMetadataOnlyLogs() Option {
+	return func(m *Merchant) {
+		m.metadataOnlyLogs = true
+	}
+}
+
+// WithCreateOrderStore remembers the results of Merchant.CreateOrderIdempotent in store.
+func WithCreateOrderStore(store CreateOrderStore) Option {
+	return func(m *Merchant) {
+		m.createOrderStore = store
+	}
+}
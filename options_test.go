@@ -0,0 +1,70 @@
+package binancepay
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestNewMerchantWithOptions(t *testing.T) {
+	const secret = "test secret key"
+	now := time.UnixMilli(1654943252000)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/binancepay/openapi/v2/order/query", r.URL.Path)
+		assert.Equal(t, "api-key", r.Header.Get("BinancePay-Certificate-SN"))
+		assert.Equal(t, "fixed-nonce", r.Header.Get("BinancePay-Nonce"))
+		assert.Equal(t, "1654943252000", r.Header.Get("BinancePay-Timestamp"))
+
+		body, err := ioutil.ReadAll(r.Body)
+		assert.Nil(t, err, err)
+		signature, err := Sign([]byte(secret), []byte(BuildPayload(string(body), "1654943252000", "fixed-nonce")))
+		assert.Nil(t, err, err)
+		assert.Equal(t, signature, r.Header.Get("BinancePay-Signature"))
+
+		_ = json.NewEncoder(w).Encode(Response[QueryOrderResult]{
+			Status: "SUCCESS",
+			Code:   "000000",
+			Data:   QueryOrderResult{MerchantTradeNo: "9825382937292"},
+		})
+	}))
+	defer server.Close()
+
+	client := NewMerchant("api-key", secret, nil, nil,
+		WithHost(server.URL),
+		WithHTTPClient(server.Client()),
+		WithClock(func() time.Time { return now }),
+		WithNonceFunc(func() string { return "fixed-nonce" }),
+		WithLogger(logger),
+	)
+
+	var resp Response[QueryOrderResult]
+	err := client.Do(&QueryOrderRequest{MerchantTradeNo: "9825382937292"}, &resp)
+	assert.Nil(t, err, err)
+	assert.Equal(t, "9825382937292", resp.Data.MerchantTradeNo)
+}
+
+func TestNewMerchantDefaults(t *testing.T) {
+	client := NewMerchant("", "", nil, nil)
+	assert.Equal(t, DefaultHost, client.host)
+	assert.Equal(t, http.DefaultClient, client.httpClient)
+	assert.NotNil(t, client.logger)
+	assert.Len(t, client.nonce(), 32)
+
+	client = NewMerchant("", "", nil, nil, WithHTTPClient(nil))
+	assert.Equal(t, http.DefaultClient, client.httpClient)
+}
+
+func TestWithCertificates(t *testing.T) {
+	client := NewMerchant("", "", nil, logger, WithCertificates(
+		Certificate{CertSerial: "broken", CertPublic: "not a key"},
+		Certificate{CertSerial: "abc", CertPublic: testDataPublicKey},
+	))
+
+	_, err := client.VerifyAndParseWebhookRequest(newSignedWebhookRequest(t, testOrderWebhookBody))
+	assert.Nil(t, err, err)
+}
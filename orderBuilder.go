@@ -0,0 +1,127 @@
+package binancepay
+
+import (
+	"github.com/shopspring/decimal"
+	"time"
+)
+
+// OrderBuilder builds a CreateOrderV2Request, e.g.
+//
+//	req, err := NewOrderBuilder().
+//		Amount(decimal.RequireFromString("9.99"), "USDT").
+//		Goods("sku-1", "game credits").
+//		Virtual().
+//		Category(CategoryGames).
+//		ExpiresIn(15 * time.Minute).
+//		ReturnTo("https://example.com/orders/1").
+//		Build()
+//
+// The request defaults to a WEB terminal and tangible goods of CategoryOthers.
+type OrderBuilder struct {
+	req       CreateOrderV2Request
+	expiresIn time.Duration
+	now       func() time.Time
+}
+
+func NewOrderBuilder() *OrderBuilder {
+	return &OrderBuilder{
+		req: CreateOrderV2Request{
+			Env: Env{TerminalType: TerminalTypeWeb},
+			Goods: Goods{
+				GoodsType:     GoodsTypeTangible,
+				GoodsCategory: CategoryOthers,
+			},
+		},
+		now: time.Now,
+	}
+}
+
+// Clock overrides time.Now used for the OrderExpireTime and its validation,
+// use the clock of the Merchant the request is sent with, see WithClock.
+func (b *OrderBuilder) Clock(now func() time.Time) *OrderBuilder {
+	b.now = now
+	return b
+}
+
+// MerchantTradeNo sets the merchantTradeNo, a unique one is generated by Build when not set.
+func (b *OrderBuilder) MerchantTradeNo(merchantTradeNo string) *OrderBuilder {
+	b.req.MerchantTradeNo = merchantTradeNo
+	return b
+}
+
+func (b *OrderBuilder) Amount(amount decimal.Decimal, currency string) *OrderBuilder {
+	b.req.OrderAmount = amount
+	b.req.Currency = currency
+	return b
+}
+
+// Goods sets the goods, referenceGoodsId defaults to the merchantTradeNo when empty.
+func (b *OrderBuilder) Goods(referenceGoodsId, name string) *OrderBuilder {
+	b.req.Goods.ReferenceGoodsId = referenceGoodsId
+	b.req.Goods.GoodsName = name
+	return b
+}
+
+// GoodsType sets the goods type, GoodsTypeTangible or GoodsTypeVirtual.
+func (b *OrderBuilder) GoodsType(goodsType string) *OrderBuilder {
+	b.req.Goods.GoodsType = goodsType
+	return b
+}
+
+func (b *OrderBuilder) Tangible() *OrderBuilder {
+	return b.GoodsType(GoodsTypeTangible)
+}
+
+func (b *OrderBuilder) Virtual() *OrderBuilder {
+	return b.GoodsType(GoodsTypeVirtual)
+}
+
+// Category sets the goods category, one of the Category constants.
+func (b *OrderBuilder) Category(category string) *OrderBuilder {
+	b.req.Goods.GoodsCategory = category
+	return b
+}
+
+// Terminal sets the terminal type, e.g. TerminalTypeApp.
+func (b *OrderBuilder) Terminal(terminalType string) *OrderBuilder {
+	b.req.Env.TerminalType = terminalType
+	return b
+}
+
+// ExpiresIn sets the OrderExpireTime to d after Build, 0 keeps the default expire time of binance pay.
+func (b *OrderBuilder) ExpiresIn(d time.Duration) *OrderBuilder {
+	b.expiresIn = d
+	return b
+}
+
+// ReturnTo sets the url to redirect to after payment.
+func (b *OrderBuilder) ReturnTo(url string) *OrderBuilder {
+	b.req.ReturnUrl = url
+	return b
+}
+
+// CancelTo sets the url to redirect to when the payment is canceled.
+func (b *OrderBuilder) CancelTo(url string) *OrderBuilder {
+	b.req.CancelUrl = url
+	return b
+}
+
+// Build returns a new validated request, failed rules are returned as *ValidationError.
+// The builder can be reused, every Build without MerchantTradeNo gets a new merchantTradeNo.
+func (b *OrderBuilder) Build() (*CreateOrderV2Request, error) {
+	now := b.now()
+	req := b.req
+	if req.MerchantTradeNo == "" {
+		req.MerchantTradeNo = Nonce()
+	}
+	if req.Goods.ReferenceGoodsId == "" {
+		req.Goods.ReferenceGoodsId = req.MerchantTradeNo
+	}
+	if b.expiresIn > 0 {
+		req.OrderExpireTime = now.Add(b.expiresIn).UnixMilli()
+	}
+	if err := req.ValidateAt(now); err != nil {
+		return nil, err
+	}
+	return &req, nil
+}
@@ -0,0 +1,82 @@
+package binancepay
+
+import (
+	"errors"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestOrderBuilder(t *testing.T) {
+	b := NewOrderBuilder().
+		Amount(decimal.RequireFromString("9.99"), "USDT").
+		Goods("", "game credits").
+		Virtual().
+		Category(CategoryGames).
+		ExpiresIn(15 * time.Minute).
+		ReturnTo("https://example.com/return")
+
+	req, err := b.Build()
+	assert.Nil(t, err, err)
+	assert.Equal(t, TerminalTypeWeb, req.Env.TerminalType)
+	assert.Equal(t, "9.99 USDT", req.Money().String())
+	assert.Equal(t, GoodsTypeVirtual, req.Goods.GoodsType)
+	assert.Equal(t, CategoryGames, req.Goods.GoodsCategory)
+	assert.Equal(t, "https://example.com/return", req.ReturnUrl)
+	assert.Regexp(t, `^[0-9a-zA-Z]{1,32}$`, req.MerchantTradeNo)
+	assert.Equal(t, req.MerchantTradeNo, req.Goods.ReferenceGoodsId)
+	assert.WithinDuration(t, time.Now().Add(15*time.Minute), time.UnixMilli(req.OrderExpireTime), time.Minute)
+
+	// every Build generates a new merchantTradeNo
+	again, err := b.Build()
+	assert.Nil(t, err, err)
+	assert.NotEqual(t, req.MerchantTradeNo, again.MerchantTradeNo)
+
+	req, err = b.MerchantTradeNo("order1").Goods("sku1", "game credits").Build()
+	assert.Nil(t, err, err)
+	assert.Equal(t, "order1", req.MerchantTradeNo)
+	assert.Equal(t, "sku1", req.Goods.ReferenceGoodsId)
+}
+
+func TestOrderBuilder_Defaults(t *testing.T) {
+	req, err := NewOrderBuilder().Amount(decimal.NewFromInt(1), "USDT").Goods("sku1", "shoes").Build()
+	assert.Nil(t, err, err)
+	assert.Equal(t, GoodsTypeTangible, req.Goods.GoodsType)
+	assert.Equal(t, CategoryOthers, req.Goods.GoodsCategory)
+	assert.Zero(t, req.OrderExpireTime)
+}
+
+func TestOrderBuilder_Invalid(t *testing.T) {
+	_, err := NewOrderBuilder().
+		Amount(decimal.RequireFromString("0.001"), "USDT").
+		Category("G000").
+		Build()
+
+	var validationErr *ValidationError
+	assert.True(t, errors.As(err, &validationErr), err)
+	var fields []string
+	for _, f := range validationErr.Fields {
+		fields = append(fields, f.Field+":"+f.Rule)
+	}
+	assert.ElementsMatch(t, []string{"orderAmount:order_amount", "goods.goodsName:required", "goods.goodsCategory:goods_category"}, fields)
+}
+
+func TestOrderBuilder_Clock(t *testing.T) {
+	now := time.UnixMilli(1655859345000)
+	req, err := NewOrderBuilder().
+		Clock(func() time.Time { return now }).
+		Amount(decimal.NewFromInt(1), "USDT").
+		Goods("sku1", "shoes").
+		ExpiresIn(15 * time.Minute).
+		Build()
+	assert.Nil(t, err, err)
+	assert.Equal(t, now.Add(15*time.Minute).UnixMilli(), req.OrderExpireTime)
+}
+
+func TestValidGoodsCategory(t *testing.T) {
+	assert.True(t, ValidGoodsCategory(CategoryGames))
+	assert.True(t, ValidGoodsCategory("Z000"))
+	assert.False(t, ValidGoodsCategory("G000"))
+	assert.False(t, ValidGoodsCategory(""))
+}
@@ -0,0 +1,117 @@
+package binancepay
+
+import (
+	"errors"
+	"fmt"
+)
+
+// OrderStatus is the status of an order returned by QueryOrderRequest.
+type OrderStatus string
+
+const (
+	OrderStatusInitial   OrderStatus = "INITIAL"
+	OrderStatusPending   OrderStatus = "PENDING"
+	OrderStatusPaid      OrderStatus = "PAID"
+	OrderStatusCanceled  OrderStatus = "CANCELED"
+	OrderStatusError     OrderStatus = "ERROR"
+	OrderStatusRefunding OrderStatus = "REFUNDING"
+	OrderStatusRefunded  OrderStatus = "REFUNDED"
+	OrderStatusExpired   OrderStatus = "EXPIRED"
+)
+
+// BizStatus is the bizStatus of a webhook notification.
+type BizStatus string
+
+const (
+	BizStatusPaySuccess     BizStatus = "PAY_SUCCESS"
+	BizStatusPayClosed      BizStatus = "PAY_CLOSED"
+	BizStatusRefundSuccess  BizStatus = "REFUND_SUCCESS"
+	BizStatusRefundRejected BizStatus = "REFUND_REJECTED"
+)
+
+// ErrIllegalTransition is returned when an order status can't follow the stored one,
+// e.g. a notification delivered out of order.
+var ErrIllegalTransition = errors.New("illegal order status transition")
+
+// orderTransitions lists the statuses each status may move to. Partial refunds
+// move an order between PAID, REFUNDING and REFUNDED more than once. Binance pay closes
+// expired orders with PAY_CLOSED, so EXPIRED may still move to CANCELED.
+var orderTransitions = map[OrderStatus][]OrderStatus{
+	OrderStatusInitial:   {OrderStatusPending, OrderStatusPaid, OrderStatusCanceled, OrderStatusError, OrderStatusExpired},
+	OrderStatusPending:   {OrderStatusPaid, OrderStatusCanceled, OrderStatusError, OrderStatusExpired},
+	OrderStatusPaid:      {OrderStatusRefunding, OrderStatusRefunded},
+	OrderStatusRefunding: {OrderStatusPaid, OrderStatusRefunded},
+	OrderStatusRefunded:  {OrderStatusRefunding},
+	OrderStatusCanceled:  {},
+	OrderStatusError:     {},
+	OrderStatusExpired:   {OrderStatusCanceled},
+}
+
+// Valid reports whether s is a known status.
+func (s OrderStatus) Valid() bool {
+	_, ok := orderTransitions[s]
+	return ok
+}
+
+// Pending reports whether the order still waits for payment.
+func (s OrderStatus) Pending() bool {
+	return s == OrderStatusInitial || s == OrderStatusPending
+}
+
+// Terminal reports whether the order was closed without payment and can't be paid anymore.
+func (s OrderStatus) Terminal() bool {
+	return s == OrderStatusCanceled || s == OrderStatusError || s == OrderStatusExpired
+}
+
+// OrderStatus returns the order status a notification with this bizStatus moves the order to.
+// ok is false for notifications which leave the order status unchanged, e.g. REFUND_REJECTED.
+func (s BizStatus) OrderStatus() (status OrderStatus, ok bool) {
+	switch s {
+	case BizStatusPaySuccess:
+		return OrderStatusPaid, true
+	case BizStatusPayClosed:
+		return OrderStatusCanceled, true
+	case BizStatusRefundSuccess:
+		return OrderStatusRefunded, true
+	}
+	return "", false
+}
+
+// CanTransition reports whether an order may move from one status to another.
+// Staying in the same status is allowed, notifications may be delivered more than once.
+func CanTransition(from, to OrderStatus) bool {
+	next, ok := orderTransitions[from]
+	if !ok || !to.Valid() {
+		return false
+	}
+	if from == to {
+		return true
+	}
+	for _, status := range next {
+		if status == to {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateTransition returns an error wrapping ErrIllegalTransition when to can't follow from.
+func ValidateTransition(from, to OrderStatus) error {
+	if !CanTransition(from, to) {
+		return fmt.Errorf("%w: %s -> %s", ErrIllegalTransition, from, to)
+	}
+	return nil
+}
+
+// NextOrderStatus returns the status of a stored order after a notification with bizStatus,
+// or an error wrapping ErrIllegalTransition when the notification doesn't fit the stored status.
+func NextOrderStatus(current OrderStatus, bizStatus BizStatus) (OrderStatus, error) {
+	next, ok := bizStatus.OrderStatus()
+	if !ok {
+		return current, nil
+	}
+	if err := ValidateTransition(current, next); err != nil {
+		return current, err
+	}
+	return next, nil
+}
@@ -0,0 +1,79 @@
+package binancepay
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestCanTransition(t *testing.T) {
+	for _, tc := range []struct {
+		from, to OrderStatus
+		legal    bool
+	}{
+		{OrderStatusInitial, OrderStatusPaid, true},
+		{OrderStatusInitial, OrderStatusExpired, true},
+		{OrderStatusPending, OrderStatusCanceled, true},
+		{OrderStatusPaid, OrderStatusPaid, true},
+		{OrderStatusPaid, OrderStatusRefunding, true},
+		{OrderStatusRefunding, OrderStatusRefunded, true},
+		{OrderStatusRefunded, OrderStatusRefunding, true},
+		{OrderStatusPaid, OrderStatusInitial, false},
+		{OrderStatusPaid, OrderStatusCanceled, false},
+		{OrderStatusCanceled, OrderStatusPaid, false},
+		{OrderStatusExpired, OrderStatusPaid, false},
+		{OrderStatusExpired, OrderStatusCanceled, true},
+		{OrderStatusCanceled, OrderStatusExpired, false},
+		{OrderStatusInitial, OrderStatusRefunded, false},
+		{OrderStatusInitial, "UNKNOWN", false},
+		{"UNKNOWN", OrderStatusPaid, false},
+	} {
+		assert.Equal(t, tc.legal, CanTransition(tc.from, tc.to), "%s -> %s", tc.from, tc.to)
+		if tc.legal {
+			assert.Nil(t, ValidateTransition(tc.from, tc.to))
+		} else {
+			assert.ErrorIs(t, ValidateTransition(tc.from, tc.to), ErrIllegalTransition)
+		}
+	}
+}
+
+func TestOrderStatusPredicates(t *testing.T) {
+	assert.True(t, OrderStatusInitial.Pending())
+	assert.False(t, OrderStatusPaid.Pending())
+	assert.True(t, OrderStatusCanceled.Terminal())
+	assert.True(t, OrderStatusExpired.Terminal())
+	assert.False(t, OrderStatusPaid.Terminal())
+	assert.False(t, OrderStatus("UNKNOWN").Terminal())
+	assert.False(t, OrderStatus("UNKNOWN").Valid())
+}
+
+func TestNextOrderStatus(t *testing.T) {
+	next, err := NextOrderStatus(OrderStatusInitial, BizStatusPaySuccess)
+	assert.Nil(t, err, err)
+	assert.Equal(t, OrderStatusPaid, next)
+
+	// a PAY_CLOSED delivered after PAY_SUCCESS is out of order
+	next, err = NextOrderStatus(OrderStatusPaid, BizStatusPayClosed)
+	assert.ErrorIs(t, err, ErrIllegalTransition)
+	assert.Equal(t, OrderStatusPaid, next)
+
+	// binance pay closes expired orders with PAY_CLOSED
+	next, err = NextOrderStatus(OrderStatusExpired, BizStatusPayClosed)
+	assert.Nil(t, err, err)
+	assert.Equal(t, OrderStatusCanceled, next)
+
+	next, err = NextOrderStatus(OrderStatusPaid, BizStatusRefundRejected)
+	assert.Nil(t, err, err)
+	assert.Equal(t, OrderStatusPaid, next)
+
+	next, err = NextOrderStatus(OrderStatusPaid, BizStatusRefundSuccess)
+	assert.Nil(t, err, err)
+	assert.Equal(t, OrderStatusRefunded, next)
+}
+
+func TestUnmarshalOrderStatus(t *testing.T) {
+	var result QueryOrderResult
+	err := json.Unmarshal([]byte(`{"merchantTradeNo":"9825382937292","status":"PAID"}`), &result)
+	assert.Nil(t, err, err)
+	assert.Equal(t, OrderStatusPaid, result.Status)
+}
@@ -11,7 +11,11 @@ func (q QueryCertificateRequest) EndPoint() string {
 	return "/binancepay/openapi/certificates"
 }
 func (q *QueryCertificateRequest) Validate() error {
-	return validate.Struct(q)
+	return validateStruct(q)
+}
+
+func (q *QueryCertificateRequest) Idempotent() bool {
+	return true
 }
 
 type Certificate struct {
@@ -15,18 +15,35 @@ func (q *QueryOrderRequest) EndPoint() string {
 	return "/binancepay/openapi/v2/order/query"
 }
 func (q *QueryOrderRequest) Validate() error {
-	return validate.Struct(q)
+	return validateStruct(q)
+}
+
+func (q *QueryOrderRequest) Idempotent() bool {
+	return true
 }
 
 type QueryOrderResult struct {
-	MerchantId      string `json:"merchantId"`
-	PrepayId        string `json:"prepayId"`
-	TransactionId   string `json:"transactionId"`
-	MerchantTradeNo string `json:"merchantTradeNo"`
-	Status          string `json:"status"`
-	Currency        string `json:"currency"`
-	OrderAmount     string `json:"orderAmount"`
-	OpenUserId      string `json:"openUserId"`
-	TransactTime    int64  `json:"transactTime"`
-	CreateTime      int64  `json:"createTime"`
+	MerchantId      string      `json:"merchantId"`
+	PrepayId        string      `json:"prepayId"`
+	TransactionId   string      `json:"transactionId"`
+	MerchantTradeNo string      `json:"merchantTradeNo"`
+	Status          OrderStatus `json:"status"`
+	Currency        string      `json:"currency"`
+	OrderAmount     string      `json:"orderAmount"`
+	OpenUserId      string      `json:"openUserId"`
+	TransactTime    int64       `json:"transactTime"`
+	CreateTime      int64       `json:"createTime"`
+	PassThroughInfo string      `json:"passThroughInfo,omitempty"` // PassThroughInfo of the CreateOrderV2Request
+
+	// checkout data of the order, may be empty once the order isn't pending
+	QrcodeLink   string `json:"qrcodeLink,omitempty"`
+	QrContent    string `json:"qrContent,omitempty"`
+	CheckoutUrl  string `json:"checkoutUrl,omitempty"`
+	Deeplink     string `json:"deeplink,omitempty"`
+	UniversalUrl string `json:"universalUrl,omitempty"`
+}
+
+// Money returns the OrderAmount in Currency, an empty OrderAmount is zero.
+func (r *QueryOrderResult) Money() (Money, error) {
+	return moneyOf(r.OrderAmount, r.Currency)
 }
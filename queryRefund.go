@@ -0,0 +1,40 @@
+package binancepay
+
+import (
+	"github.com/shopspring/decimal"
+)
+
+var _ IRequest = &QueryRefundRequest{}
+
+// QueryRefundRequest doc https://developers.binance.com/docs/binance-pay/api-order-refund-query
+type QueryRefundRequest struct {
+	RefundRequestId string `json:"refundRequestId" validate:"required"`
+}
+
+func (q *QueryRefundRequest) EndPoint() string {
+	return "/binancepay/openapi/order/refund/query"
+}
+
+func (q *QueryRefundRequest) Validate() error {
+	return validateStruct(q)
+}
+
+func (q *QueryRefundRequest) Idempotent() bool {
+	return true
+}
+
+type QueryRefundResult struct {
+	RefundRequestId   string          `json:"refundRequestId"`
+	PrepayId          string          `json:"prepayId"`
+	OrderAmount       decimal.Decimal `json:"orderAmount"`
+	RefundedAmount    decimal.Decimal `json:"refundedAmount"`
+	RefundAmount      decimal.Decimal `json:"refundAmount"`
+	RemainingAttempts int             `json:"remainingAttempts"`
+	PayerOpenId       string          `json:"payerOpenId"`
+	RefundStatus      string          `json:"refundStatus"` // REFUNDING, REFUNDED, REFUND_FAILED
+}
+
+// Money returns the RefundAmount in currency, the currency of the refunded order.
+func (r *QueryRefundResult) Money(currency string) Money {
+	return NewMoney(r.RefundAmount, currency)
+}
@@ -0,0 +1,192 @@
+package binancepay
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	DefaultThrottleBackoff    = time.Second
+	DefaultMaxThrottleBackoff = 30 * time.Second
+
+	// maxThrottledAttempts bounds how often a throttled request is resent after backing off.
+	maxThrottledAttempts = 3
+)
+
+// RateLimit configures a token bucket: Rate requests per second with bursts of up to Burst requests.
+// A zero Rate doesn't limit requests, the bucket then only backs off after throttled requests.
+//
+// A throttled request pauses the bucket for the Retry-After of the response, or else for ThrottleBackoff
+// doubled on every consecutive throttled request up to MaxThrottleBackoff.
+type RateLimit struct {
+	Rate               float64
+	Burst              int
+	ThrottleBackoff    time.Duration // DefaultThrottleBackoff when 0
+	MaxThrottleBackoff time.Duration // DefaultMaxThrottleBackoff when 0
+}
+
+type tokenBucket struct {
+	limit RateLimit
+
+	mu           sync.Mutex
+	tokens       float64
+	last         time.Time
+	blockedUntil time.Time
+	penalty      time.Duration
+}
+
+func newTokenBucket(limit RateLimit) *tokenBucket {
+	if limit.Burst < 1 {
+		limit.Burst = 1
+	}
+	if limit.ThrottleBackoff <= 0 {
+		limit.ThrottleBackoff = DefaultThrottleBackoff
+	}
+	if limit.MaxThrottleBackoff <= 0 {
+		limit.MaxThrottleBackoff = DefaultMaxThrottleBackoff
+	}
+	return &tokenBucket{limit: limit, tokens: float64(limit.Burst)}
+}
+
+// reserve takes a token and returns how long to wait before using it.
+func (b *tokenBucket) reserve(now time.Time) time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var wait time.Duration
+	if b.limit.Rate > 0 {
+		if !b.last.IsZero() && now.After(b.last) {
+			b.tokens += now.Sub(b.last).Seconds() * b.limit.Rate
+			if b.tokens > float64(b.limit.Burst) {
+				b.tokens = float64(b.limit.Burst)
+			}
+		}
+		b.last = now
+		b.tokens--
+		if b.tokens < 0 {
+			wait = time.Duration(-b.tokens / b.limit.Rate * float64(time.Second))
+		}
+	}
+	if blocked := b.blockedUntil.Sub(now); blocked > wait {
+		wait = blocked
+	}
+	return wait
+}
+
+// cancel returns a token which was reserved but not used.
+func (b *tokenBucket) cancel() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.limit.Rate > 0 {
+		b.tokens++
+	}
+}
+
+// throttled pauses the bucket after the API rejected a request for exceeding its quota.
+func (b *tokenBucket) throttled(now time.Time, retryAfter time.Duration) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.penalty == 0 {
+		b.penalty = b.limit.ThrottleBackoff
+	} else {
+		b.penalty *= 2
+	}
+	if b.penalty > b.limit.MaxThrottleBackoff {
+		b.penalty = b.limit.MaxThrottleBackoff
+	}
+	pause := b.penalty
+	if retryAfter > 0 {
+		pause = retryAfter
+	}
+	if until := now.Add(pause); until.After(b.blockedUntil) {
+		b.blockedUntil = until
+	}
+	// the API accepts no request during the pause, so don't let the bucket release a burst afterwards
+	if b.tokens > 0 {
+		b.tokens = 0
+	}
+}
+
+// succeeded resets the throttle backoff.
+func (b *tokenBucket) succeeded() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.penalty = 0
+}
+
+// rateLimiter holds the global bucket and the buckets of single endpoints, see WithRateLimit.
+type rateLimiter struct {
+	global    *tokenBucket
+	endpoints map[string]*tokenBucket
+}
+
+func (l *rateLimiter) buckets(endpoint string) []*tokenBucket {
+	buckets := make([]*tokenBucket, 0, 2)
+	if l.global != nil {
+		buckets = append(buckets, l.global)
+	}
+	if b, ok := l.endpoints[endpoint]; ok {
+		buckets = append(buckets, b)
+	}
+	return buckets
+}
+
+// wait blocks until a request to endpoint is allowed or ctx is done.
+func (l *rateLimiter) wait(ctx context.Context, now time.Time, endpoint string) error {
+	buckets := l.buckets(endpoint)
+	var wait time.Duration
+	for _, b := range buckets {
+		if w := b.reserve(now); w > wait {
+			wait = w
+		}
+	}
+	if wait <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		for _, b := range buckets {
+			b.cancel()
+		}
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// done records the outcome of a request to endpoint.
+func (l *rateLimiter) done(now time.Time, endpoint string, err error) {
+	buckets := l.buckets(endpoint)
+	if len(buckets) == 0 {
+		return
+	}
+	if !IsRateLimited(err) {
+		for _, b := range buckets {
+			b.succeeded()
+		}
+		return
+	}
+	var retryAfter time.Duration
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		retryAfter = apiErr.RetryAfter
+	}
+	// the quota of an endpoint with its own bucket was exceeded, other endpoints can go on
+	buckets[len(buckets)-1].throttled(now, retryAfter)
+}
+
+// parseRetryAfter parses a Retry-After header given in seconds, http dates are not supported.
+func parseRetryAfter(value string) time.Duration {
+	seconds, err := strconv.Atoi(value)
+	if err != nil || seconds < 0 {
+		return 0
+	}
+	return time.Duration(seconds) * time.Second
+}
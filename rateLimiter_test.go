@@ -0,0 +1,151 @@
+package binancepay
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestTokenBucket(t *testing.T) {
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	b := newTokenBucket(RateLimit{Rate: 2, Burst: 2, ThrottleBackoff: time.Second, MaxThrottleBackoff: 3 * time.Second})
+
+	assert.Equal(t, time.Duration(0), b.reserve(now))
+	assert.Equal(t, time.Duration(0), b.reserve(now))
+	assert.Equal(t, 500*time.Millisecond, b.reserve(now))
+	b.cancel()
+
+	// refilled after a second, up to the burst
+	now = now.Add(10 * time.Second)
+	assert.Equal(t, time.Duration(0), b.reserve(now))
+	assert.Equal(t, time.Duration(0), b.reserve(now))
+	assert.Equal(t, 500*time.Millisecond, b.reserve(now))
+
+	// throttled requests back off exponentially, a success resets the backoff
+	now = now.Add(10 * time.Second)
+	b.throttled(now, 0)
+	assert.Equal(t, time.Second, b.reserve(now))
+	b.throttled(now, 0)
+	assert.Equal(t, 2*time.Second, b.reserve(now))
+	b.throttled(now, 0)
+	assert.Equal(t, 3*time.Second, b.reserve(now))
+	b.throttled(now, 5*time.Second)
+	assert.Equal(t, 5*time.Second, b.reserve(now))
+	b.succeeded()
+	now = now.Add(10 * time.Second)
+	b.throttled(now, 0)
+	assert.Equal(t, time.Second, b.reserve(now))
+}
+
+func TestRateLimiter_EndpointThrottled(t *testing.T) {
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	m := NewMerchant("key", "secret", nil, logger,
+		WithRateLimit(RateLimit{}),
+		WithEndpointRateLimit("/a", RateLimit{ThrottleBackoff: time.Second}),
+	)
+
+	m.limiter.done(now, "/a", ErrTooManyRequests)
+	assert.Equal(t, time.Second, m.limiter.endpoints["/a"].reserve(now))
+	assert.Equal(t, time.Duration(0), m.limiter.global.reserve(now))
+
+	m.limiter.done(now, "/b", &APIError{HTTPStatus: http.StatusTooManyRequests, RetryAfter: 2 * time.Second})
+	assert.Equal(t, 2*time.Second, m.limiter.global.reserve(now))
+}
+
+func TestRateLimiter_UnlimitedEndpointThrottled(t *testing.T) {
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	m := NewMerchant("key", "secret", nil, logger, WithEndpointRateLimit("/other", RateLimit{}))
+
+	assert.NotPanics(t, func() { m.limiter.done(now, "/a", ErrTooManyRequests) })
+	assert.Equal(t, time.Duration(0), m.limiter.endpoints["/other"].reserve(now))
+}
+
+func TestMerchant_DoThrottledCode(t *testing.T) {
+	var calls int32
+	httpClient := mockHttpClient(func(request *http.Request) (*http.Response, error) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       ioutil.NopCloser(bytes.NewBufferString(`{"status":"FAIL","code":"400013","errorMessage":"too many requests"}`)),
+			}, nil
+		}
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       ioutil.NopCloser(bytes.NewBufferString(`{"status":"SUCCESS","code":"000000","data":{"status":"PAID"}}`)),
+		}, nil
+	})
+	// the endpoint has no bucket of its own
+	m := NewMerchant("key", "secret", nil, logger,
+		WithHTTPClient(httpClient),
+		WithEndpointRateLimit("/other", RateLimit{}),
+	)
+
+	var resp Response[QueryOrderResult]
+	err := m.Do(&QueryOrderRequest{MerchantTradeNo: "abc"}, &resp)
+	assert.Nil(t, err, err)
+	assert.Equal(t, OrderStatusPaid, resp.Data.Status)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+}
+
+func TestMerchant_DoThrottled(t *testing.T) {
+	var calls int32
+	httpClient := mockHttpClient(func(request *http.Request) (*http.Response, error) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			return &http.Response{
+				StatusCode: http.StatusTooManyRequests,
+				Body:       ioutil.NopCloser(bytes.NewReader(nil)),
+			}, nil
+		}
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       ioutil.NopCloser(bytes.NewBufferString(`{"status":"SUCCESS","code":"000000","data":{"status":"PAID"}}`)),
+		}, nil
+	})
+	m := NewMerchant("key", "secret", nil, logger,
+		WithHTTPClient(httpClient),
+		WithRateLimit(RateLimit{ThrottleBackoff: 20 * time.Millisecond}),
+	)
+
+	start := time.Now()
+	var resp Response[QueryOrderResult]
+	err := m.Do(&QueryOrderRequest{MerchantTradeNo: "abc"}, &resp)
+	assert.Nil(t, err, err)
+	assert.Equal(t, OrderStatusPaid, resp.Data.Status)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+	assert.GreaterOrEqual(t, time.Since(start), 20*time.Millisecond)
+}
+
+func TestMerchant_DoRateLimitedContext(t *testing.T) {
+	var calls int32
+	httpClient := mockHttpClient(func(request *http.Request) (*http.Response, error) {
+		atomic.AddInt32(&calls, 1)
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       ioutil.NopCloser(bytes.NewBufferString(`{"status":"SUCCESS","code":"000000","data":{}}`)),
+		}, nil
+	})
+	m := NewMerchant("key", "secret", nil, logger,
+		WithHTTPClient(httpClient),
+		WithEndpointRateLimit("/binancepay/openapi/v2/order/query", RateLimit{Rate: 1, Burst: 1}),
+	)
+
+	var resp Response[QueryOrderResult]
+	err := m.Do(&QueryOrderRequest{MerchantTradeNo: "abc"}, &resp)
+	assert.Nil(t, err, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	err = m.DoContext(ctx, &QueryOrderRequest{MerchantTradeNo: "abc"}, &resp)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+
+	// other endpoints aren't limited
+	err = m.Do(&CloseOrderRequest{MerchantTradeNo: "abc"}, &Response[json.RawMessage]{})
+	assert.Nil(t, err, err)
+}
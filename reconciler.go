@@ -0,0 +1,262 @@
+package binancepay
+
+import (
+	"context"
+	"fmt"
+	"go.uber.org/zap"
+	"sync"
+	"time"
+)
+
+const (
+	DefaultReconcileInterval    = time.Minute
+	DefaultReconcileConcurrency = 4
+	DefaultReconcileRate        = 10 // queries per second
+)
+
+// PendingOrder is an order the merchant still considers unpaid.
+type PendingOrder struct {
+	MerchantTradeNo string
+	Status          OrderStatus // last known status, OrderStatusInitial when empty
+	ExpireTime      int64       // CreateOrderV2Result.ExpireTime in milliseconds, 0 when unknown
+}
+
+// PendingOrderSource lists the orders to reconcile, typically from the merchant's order table.
+type PendingOrderSource interface {
+	PendingOrders(ctx context.Context) ([]PendingOrder, error)
+}
+
+// PendingOrderSourceFunc adapts a function to PendingOrderSource.
+type PendingOrderSourceFunc func(ctx context.Context) ([]PendingOrder, error)
+
+func (f PendingOrderSourceFunc) PendingOrders(ctx context.Context) ([]PendingOrder, error) {
+	return f(ctx)
+}
+
+type ReconcilerOption func(r *Reconciler)
+
+// WithReconcileInterval overrides DefaultReconcileInterval, the delay between two reconcile rounds.
+func WithReconcileInterval(interval time.Duration) ReconcilerOption {
+	return func(r *Reconciler) {
+		r.interval = interval
+	}
+}
+
+// WithReconcileConcurrency overrides DefaultReconcileConcurrency, the number of parallel order queries.
+func WithReconcileConcurrency(n int) ReconcilerOption {
+	return func(r *Reconciler) {
+		r.concurrency = n
+	}
+}
+
+// WithReconcileRate overrides DefaultReconcileRate, the maximum number of order queries per second.
+func WithReconcileRate(perSecond float64) ReconcilerOption {
+	return func(r *Reconciler) {
+		r.rate = perSecond
+	}
+}
+
+// WithReconcileExpireGrace sets how long after ExpireTime an unpaid order is still queried, 0 by default.
+func WithReconcileExpireGrace(grace time.Duration) ReconcilerOption {
+	return func(r *Reconciler) {
+		r.expireGrace = grace
+	}
+}
+
+// Reconciler polls QueryOrderRequest for pending orders, so orders whose webhook was missed still get settled.
+// Status changes are passed to the same OrderNotiHandler the WebhookHandler uses: PAID as BizStatusPaySuccess,
+// CANCELED, EXPIRED and ERROR as BizStatusPayClosed.
+//
+// An order is no longer queried once a change was handled, or once it is past its ExpireTime and grace.
+type Reconciler struct {
+	merchant *Merchant
+	source   PendingOrderSource
+	onChange OrderNotiHandler
+
+	interval    time.Duration
+	concurrency int
+	rate        float64
+	expireGrace time.Duration
+
+	mu   sync.Mutex
+	done map[string]bool // merchantTradeNo of orders no longer queried
+}
+
+func NewReconciler(m *Merchant, source PendingOrderSource, onChange OrderNotiHandler, opts ...ReconcilerOption) *Reconciler {
+	r := &Reconciler{
+		merchant:    m,
+		source:      source,
+		onChange:    onChange,
+		interval:    DefaultReconcileInterval,
+		concurrency: DefaultReconcileConcurrency,
+		rate:        DefaultReconcileRate,
+		done:        map[string]bool{},
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	if r.concurrency < 1 {
+		r.concurrency = 1
+	}
+	return r
+}
+
+// Run reconciles every interval until ctx is done.
+func (r *Reconciler) Run(ctx context.Context) error {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+	for {
+		if err := r.ReconcileOnce(ctx); err != nil {
+			r.merchant.logger.Error("failed to reconcile orders", zap.Error(err))
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// ReconcileOnce queries every pending order once.
+func (r *Reconciler) ReconcileOnce(ctx context.Context) error {
+	orders, err := r.source.PendingOrders(ctx)
+	if err != nil {
+		return fmt.Errorf("source.PendingOrders(): %w", err)
+	}
+	r.prune(orders)
+
+	var limiter <-chan time.Time
+	if r.rate > 0 {
+		ticker := time.NewTicker(time.Duration(float64(time.Second) / r.rate))
+		defer ticker.Stop()
+		limiter = ticker.C
+	}
+
+	sem := make(chan struct{}, r.concurrency)
+	var wg sync.WaitGroup
+	for _, order := range orders {
+		if r.isDone(order.MerchantTradeNo) {
+			continue
+		}
+		if limiter != nil {
+			select {
+			case <-ctx.Done():
+			case <-limiter:
+			}
+		}
+		select {
+		case <-ctx.Done():
+		case sem <- struct{}{}:
+		}
+		if ctx.Err() != nil {
+			break
+		}
+
+		wg.Add(1)
+		go func(order PendingOrder) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			r.reconcile(ctx, order)
+		}(order)
+	}
+	wg.Wait()
+	return ctx.Err()
+}
+
+func (r *Reconciler) reconcile(ctx context.Context, order PendingOrder) {
+	logger := r.merchant.logger.With(zap.String("merchantTradeNo", order.MerchantTradeNo))
+
+	expired := order.ExpireTime > 0 && r.merchant.now().After(time.UnixMilli(order.ExpireTime).Add(r.expireGrace))
+
+	var resp Response[QueryOrderResult]
+	err := r.merchant.DoContext(ctx, &QueryOrderRequest{MerchantTradeNo: order.MerchantTradeNo}, &resp)
+	if err != nil {
+		if IsNotFound(err) && expired {
+			r.markDone(order.MerchantTradeNo)
+		}
+		logger.Warn("failed to query pending order", zap.Error(err))
+		return
+	}
+
+	known := order.Status
+	if known == "" {
+		known = OrderStatusInitial
+	}
+	status := resp.Data.Status
+	bizStatus, changed := reconciledBizStatus(known, status)
+	if !changed {
+		// a settled order doesn't change anymore, e.g. one the source lists with its final status
+		if settled := status.Valid() && !status.Pending(); settled || expired {
+			logger.Info("stop reconciling order", zap.String("status", string(status)), zap.Bool("expired", expired))
+			r.markDone(order.MerchantTradeNo)
+		}
+		return
+	}
+
+	noti, err := orderNotiFromQuery(&resp.Data)
+	if err != nil {
+		logger.Error("failed to build order notification", zap.Error(err))
+		return
+	}
+	if err = r.onChange(ctx, noti, bizStatus); err != nil {
+		logger.Error("failed to handle reconciled order", zap.String("status", string(status)), zap.Error(err))
+		return
+	}
+	r.markDone(order.MerchantTradeNo)
+}
+
+// reconciledBizStatus returns the notification matching a status change of a pending order.
+func reconciledBizStatus(known, status OrderStatus) (BizStatus, bool) {
+	if status == known || status.Pending() {
+		return "", false
+	}
+	switch status {
+	case OrderStatusPaid, OrderStatusRefunding, OrderStatusRefunded:
+		return BizStatusPaySuccess, true
+	case OrderStatusCanceled, OrderStatusExpired, OrderStatusError:
+		return BizStatusPayClosed, true
+	}
+	return "", false
+}
+
+func orderNotiFromQuery(result *QueryOrderResult) (*OrderNoti, error) {
+	amount, err := result.Money()
+	if err != nil {
+		return nil, fmt.Errorf("result.Money(): %w", err)
+	}
+	return &OrderNoti{
+		MerchantTradeNo: result.MerchantTradeNo,
+		TotalFee:        amount.Amount,
+		TransactTime:    result.TransactTime,
+		Currency:        amount.Currency,
+		OpenUserId:      result.OpenUserId,
+		TransactionId:   result.TransactionId,
+	}, nil
+}
+
+func (r *Reconciler) isDone(merchantTradeNo string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.done[merchantTradeNo]
+}
+
+func (r *Reconciler) markDone(merchantTradeNo string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.done[merchantTradeNo] = true
+}
+
+// prune forgets orders which left the source.
+func (r *Reconciler) prune(orders []PendingOrder) {
+	pending := make(map[string]bool, len(orders))
+	for _, order := range orders {
+		pending[order.MerchantTradeNo] = true
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for merchantTradeNo := range r.done {
+		if !pending[merchantTradeNo] {
+			delete(r.done, merchantTradeNo)
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package binancepay
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"sync"
+	"testing"
+	"time"
+)
+
+type reconcileEvent struct {
+	merchantTradeNo string
+	bizStatus       BizStatus
+}
+
+func TestReconciler_ReconcileOnce(t *testing.T) {
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	mu := &sync.Mutex{}
+	statuses := map[string]OrderStatus{
+		"paid":     OrderStatusPaid,
+		"closed":   OrderStatusCanceled,
+		"waiting":  OrderStatusInitial,
+		"expiring": OrderStatusInitial,
+		"settled":  OrderStatusPaid,
+	}
+	queries := map[string]int{}
+	m := NewMerchant("key", "secret", nil, logger,
+		WithHTTPClient(queryOrderHttpClient(t, mu, statuses, queries)),
+		WithClock(func() time.Time { return now }),
+	)
+
+	pending := []PendingOrder{
+		{MerchantTradeNo: "paid"},
+		{MerchantTradeNo: "closed", Status: OrderStatusInitial},
+		{MerchantTradeNo: "waiting", ExpireTime: now.Add(time.Hour).UnixMilli()},
+		{MerchantTradeNo: "expiring", ExpireTime: now.Add(-time.Minute).UnixMilli()},
+		{MerchantTradeNo: "missing", ExpireTime: now.Add(-time.Minute).UnixMilli()},
+		{MerchantTradeNo: "settled", Status: OrderStatusPaid},
+	}
+	var events []reconcileEvent
+	r := NewReconciler(m,
+		PendingOrderSourceFunc(func(ctx context.Context) ([]PendingOrder, error) {
+			return pending, nil
+		}),
+		func(ctx context.Context, noti *OrderNoti, bizStatus BizStatus) error {
+			mu.Lock()
+			defer mu.Unlock()
+			assert.Equal(t, "USDT", noti.Currency)
+			assert.Equal(t, "10.5", noti.TotalFee.String())
+			events = append(events, reconcileEvent{noti.MerchantTradeNo, bizStatus})
+			return nil
+		},
+		WithReconcileConcurrency(2),
+		WithReconcileRate(1000),
+	)
+
+	err := r.ReconcileOnce(context.Background())
+	assert.Nil(t, err, err)
+	assert.ElementsMatch(t, []reconcileEvent{
+		{"paid", BizStatusPaySuccess},
+		{"closed", BizStatusPayClosed},
+	}, events)
+
+	// settled and expired orders are not queried again, even if the source still lists them
+	err = r.ReconcileOnce(context.Background())
+	assert.Nil(t, err, err)
+	assert.Len(t, events, 2)
+	assert.Equal(t, map[string]int{"paid": 1, "closed": 1, "waiting": 2, "expiring": 1, "missing": 1, "settled": 1}, queries)
+
+	// a pending order which gets paid later is reported once
+	mu.Lock()
+	statuses["waiting"] = OrderStatusPaid
+	mu.Unlock()
+	err = r.ReconcileOnce(context.Background())
+	assert.Nil(t, err, err)
+	assert.Equal(t, reconcileEvent{"waiting", BizStatusPaySuccess}, events[2])
+}
+
+func TestReconciler_HandlerErrorRetries(t *testing.T) {
+	mu := &sync.Mutex{}
+	queries := map[string]int{}
+	m := NewMerchant("key", "secret", nil, logger,
+		WithHTTPClient(queryOrderHttpClient(t, mu, map[string]OrderStatus{"paid": OrderStatusPaid}, queries)),
+	)
+
+	calls := 0
+	r := NewReconciler(m,
+		PendingOrderSourceFunc(func(ctx context.Context) ([]PendingOrder, error) {
+			return []PendingOrder{{MerchantTradeNo: "paid"}}, nil
+		}),
+		func(ctx context.Context, noti *OrderNoti, bizStatus BizStatus) error {
+			calls++
+			if calls == 1 {
+				return assert.AnError
+			}
+			return nil
+		},
+		WithReconcileRate(0),
+	)
+
+	for i := 0; i < 3; i++ {
+		err := r.ReconcileOnce(context.Background())
+		assert.Nil(t, err, err)
+	}
+	assert.Equal(t, 2, calls)
+	assert.Equal(t, 2, queries["paid"])
+}
+
+func TestReconciler_RunStopsWithContext(t *testing.T) {
+	mu := &sync.Mutex{}
+	m := NewMerchant("key", "secret", nil, logger,
+		WithHTTPClient(queryOrderHttpClient(t, mu, map[string]OrderStatus{}, map[string]int{})),
+	)
+	r := NewReconciler(m,
+		PendingOrderSourceFunc(func(ctx context.Context) ([]PendingOrder, error) {
+			return nil, nil
+		}),
+		func(ctx context.Context, noti *OrderNoti, bizStatus BizStatus) error { return nil },
+		WithReconcileInterval(time.Millisecond),
+	)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	err := r.Run(ctx)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
@@ -0,0 +1,118 @@
+package binancepay
+
+import (
+	"bytes"
+	"encoding/json"
+	"go.uber.org/zap"
+	"strings"
+)
+
+// Redacted replaces masked values in logs.
+const Redacted = "***"
+
+// defaultRedactedFields are the JSON fields always masked in logged bodies, the identity of the payer
+// sent in OrderNotiPayerInfo and the contact details of the Buyer and the Shipping of orders.
+var defaultRedactedFields = []string{
+	"firstName", "middleName", "lastName", "walletId", "country", "city", "address",
+	"identityType", "identityNumber", "dateOfBirth", "placeOfBirth", "nationality",
+	"buyerPhoneNo", "buyerEmail", "shippingPhoneNo", "zipCode",
+}
+
+// DefaultRedactedFields returns the JSON fields always masked in logged bodies.
+func DefaultRedactedFields() []string {
+	return append([]string(nil), defaultRedactedFields...)
+}
+
+// redactor masks secrets and personal data before they are logged, see WithRedactedFields and WithMetadataOnlyLogs.
+type redactor struct {
+	fields       map[string]bool // lower case JSON field names
+	metadataOnly bool
+}
+
+func newRedactor(fields []string, metadataOnly bool) *redactor {
+	r := &redactor{fields: map[string]bool{}, metadataOnly: metadataOnly}
+	for _, field := range defaultRedactedFields {
+		r.fields[strings.ToLower(field)] = true
+	}
+	for _, field := range fields {
+		r.fields[strings.ToLower(field)] = true
+	}
+	return r
+}
+
+// secret masks an API key or a signature, the first characters of long values are kept to tell API keys apart.
+func (r *redactor) secret(s string) string {
+	if len(s) <= 16 {
+		return Redacted
+	}
+	return s[:4] + Redacted
+}
+
+// body returns the log field of a request or response body, or only its size with metadata-only logs
+// and for bodies which can't be redacted.
+func (r *redactor) body(key string, body []byte) zap.Field {
+	if r.metadataOnly {
+		return zap.Int(key+"Size", len(body))
+	}
+	redacted, ok := r.redactJSON(body)
+	if !ok {
+		return zap.Int(key+"Size", len(body))
+	}
+	return zap.ByteString(key, redacted)
+}
+
+// redactJSON masks the configured fields of a JSON body, including JSON encoded in string values
+// like the data of webhooks. ok is false for bodies which aren't JSON.
+func (r *redactor) redactJSON(body []byte) (redacted []byte, ok bool) {
+	v, ok := decodeJSON(body)
+	if !ok {
+		return nil, false
+	}
+	redacted, err := json.Marshal(r.redactValue(v))
+	if err != nil {
+		return nil, false
+	}
+	return redacted, true
+}
+
+func (r *redactor) redactValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for key, value := range v {
+			if r.fields[strings.ToLower(key)] {
+				v[key] = Redacted
+				continue
+			}
+			v[key] = r.redactValue(value)
+		}
+		return v
+	case []interface{}:
+		for i, value := range v {
+			v[i] = r.redactValue(value)
+		}
+		return v
+	case string:
+		if nested, ok := decodeJSON([]byte(v)); ok {
+			if redacted, err := json.Marshal(r.redactValue(nested)); err == nil {
+				return string(redacted)
+			}
+		}
+		return v
+	}
+	return v
+}
+
+// decodeJSON decodes JSON objects and arrays, numbers are kept as json.Number.
+func decodeJSON(data []byte) (interface{}, bool) {
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 || (data[0] != '{' && data[0] != '[') {
+		return nil, false
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var v interface{}
+	if err := decoder.Decode(&v); err != nil {
+		return nil, false
+	}
+	return v, true
+}
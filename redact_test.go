@@ -0,0 +1,115 @@
+package binancepay
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+const testPayerWebhookBody = `{"bizType":"PAY","data":"{\"merchantTradeNo\":\"9825382937292\",\"totalFee\":0.88000000,\"payerInfo\":{\"firstName\":\"John\",\"lastName\":\"Doe\",\"identityNumber\":\"X1234567\",\"dateOfBirth\":\"1990-01-01\"}}","bizId":29383937493038367292,"bizStatus":"PAY_SUCCESS"}`
+
+func TestRedactor_RedactJSON(t *testing.T) {
+	r := newRedactor(nil, false)
+	redactJSON := func(body []byte) string {
+		redacted, ok := r.redactJSON(body)
+		assert.True(t, ok)
+		return string(redacted)
+	}
+
+	redacted := redactJSON([]byte(testPayerWebhookBody))
+	for _, pii := range []string{"John", "Doe", "X1234567", "1990-01-01"} {
+		assert.NotContains(t, redacted, pii)
+	}
+	assert.Contains(t, redacted, `\"identityNumber\":\"***\"`)
+	assert.Contains(t, redacted, `\"merchantTradeNo\":\"9825382937292\"`)
+	assert.Contains(t, redacted, `\"totalFee\":0.88000000`)
+	assert.Contains(t, redacted, `"bizId":29383937493038367292`)
+
+	order, err := json.Marshal(newMarketplaceOrderRequest())
+	assert.Nil(t, err, err)
+	redacted = redactJSON(order)
+	for _, pii := range []string{"Jane", "jane@example.com", "98765432", "91234567", "048616"} {
+		assert.NotContains(t, redacted, pii)
+	}
+
+	r = newRedactor([]string{"ReturnUrl"}, false)
+	assert.Equal(t, `{"items":[{"returnUrl":"***"}]}`, redactJSON([]byte(`{"items":[{"returnUrl":"https://example.com?token=1"}]}`)))
+
+	// bodies which can't be redacted are logged by size only
+	_, ok := r.redactJSON([]byte("firstName=John"))
+	assert.False(t, ok)
+	assert.Equal(t, zap.Int("bodySize", 14), r.body("body", []byte("firstName=John")))
+
+	// the defaults can't be changed by callers
+	fields := DefaultRedactedFields()
+	fields[0] = "merchantTradeNo"
+	assert.Equal(t, "firstName", DefaultRedactedFields()[0])
+
+	assert.Equal(t, "***", r.secret("short"))
+	assert.Equal(t, "abcd***", r.secret("abcdefghijklmnopqrstuvwxyz"))
+}
+
+func newObservedMerchant(opts ...Option) (*Merchant, *observer.ObservedLogs) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	httpClient := mockHttpClient(func(request *http.Request) (*http.Response, error) {
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       ioutil.NopCloser(bytes.NewBufferString(`{"status":"SUCCESS","code":"000000","data":{"openUserId":"user-1"}}`)),
+		}, nil
+	})
+	opts = append([]Option{WithHTTPClient(httpClient)}, opts...)
+	return NewMerchant("api-key-0123456789abcdef", "secret", nil, zap.New(core), opts...), logs
+}
+
+func TestMerchant_DoRedactsLogs(t *testing.T) {
+	m, logs := newObservedMerchant(WithRedactedFields("openUserId"))
+	err := m.Do(&QueryOrderRequest{MerchantTradeNo: "abc"}, &Response[QueryOrderResult]{})
+	assert.Nil(t, err, err)
+
+	requestLogs := logs.FilterMessage("new request").All()
+	assert.Len(t, requestLogs, 1)
+	header := requestLogs[0].ContextMap()["header"].([]interface{})
+	assert.Equal(t, "api-***", header[0])
+	assert.Len(t, header[3], 4+len(Redacted))
+	assert.True(t, strings.HasSuffix(header[3].(string), Redacted))
+	assert.Equal(t, `{"merchantTradeNo":"abc"}`, requestLogs[0].ContextMap()["body"])
+
+	respLogs := logs.FilterMessage("got resp").All()
+	assert.Len(t, respLogs, 1)
+	assert.Equal(t, `{"code":"000000","data":{"openUserId":"***"},"status":"SUCCESS"}`, respLogs[0].ContextMap()["body"])
+}
+
+func TestMerchant_MetadataOnlyLogs(t *testing.T) {
+	m, logs := newObservedMerchant(WithMetadataOnlyLogs())
+	err := m.Do(&QueryOrderRequest{MerchantTradeNo: "abc"}, &Response[QueryOrderResult]{})
+	assert.Nil(t, err, err)
+
+	for _, entry := range logs.All() {
+		_, ok := entry.ContextMap()["body"]
+		assert.False(t, ok, entry.Message)
+	}
+	requestLogs := logs.FilterMessage("new request").All()
+	assert.Len(t, requestLogs, 1)
+	assert.Equal(t, int64(len(`{"merchantTradeNo":"abc"}`)), requestLogs[0].ContextMap()["bodySize"])
+}
+
+func TestMerchant_VerifyWebhookRedactsLogs(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	m := NewMerchant("", "", fixedPublicKeyTestCache{exist: true, publicKey: testDataPublicKey}, zap.New(core))
+
+	_, err := m.VerifyAndParseWebhookRequest(newSignedWebhookRequest(t, testPayerWebhookBody))
+	assert.Nil(t, err, err)
+
+	entries := logs.FilterMessage("verify and parse webhook request").All()
+	assert.Len(t, entries, 1)
+	fields := entries[0].ContextMap()
+	assert.NotContains(t, fields["body"], "X1234567")
+	assert.True(t, strings.HasSuffix(fields["Binancepay-Signature"].(string), Redacted))
+}
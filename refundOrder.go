@@ -0,0 +1,45 @@
+package binancepay
+
+import (
+	"github.com/shopspring/decimal"
+)
+
+var _ IRequest = &RefundOrderRequest{}
+
+// RefundOrderRequest doc https://developers.binance.com/docs/binance-pay/api-order-refund
+type RefundOrderRequest struct {
+	RefundRequestId string          `json:"refundRequestId" validate:"required,max=64"` // The unique ID assigned by the merchant to identify a refund request.
+	PrepayId        string          `json:"prepayId" validate:"required"`
+	RefundAmount    decimal.Decimal `json:"refundAmount" validate:"required"`
+	RefundReason    string          `json:"refundReason,omitempty" validate:"max=256"` // Optional.
+	WebhookUrl      string          `json:"webhookUrl,omitempty"`                      // Optional. Overrides the default refund webhook url.
+}
+
+func (r *RefundOrderRequest) EndPoint() string {
+	return "/binancepay/openapi/order/refund"
+}
+
+func (r *RefundOrderRequest) Validate() error {
+	return validateStruct(r)
+}
+
+// Money returns the RefundAmount in currency, the currency of the refunded order.
+func (r *RefundOrderRequest) Money(currency string) Money {
+	return NewMoney(r.RefundAmount, currency)
+}
+
+type RefundOrderResult struct {
+	RefundRequestId   string          `json:"refundRequestId"`
+	PrepayId          string          `json:"prepayId"`
+	OrderAmount       decimal.Decimal `json:"orderAmount"`
+	RefundedAmount    decimal.Decimal `json:"refundedAmount"` // The total amount refunded so far, including this request.
+	RefundAmount      decimal.Decimal `json:"refundAmount"`
+	RemainingAttempts int             `json:"remainingAttempts"`
+	PayerOpenId       string          `json:"payerOpenId"`
+	DuplicateRequest  string          `json:"duplicateRequest"` // "Y" when refundRequestId was already submitted before, otherwise "N"
+}
+
+// Money returns the RefundAmount in currency, the currency of the refunded order.
+func (r *RefundOrderResult) Money(currency string) Money {
+	return NewMoney(r.RefundAmount, currency)
+}
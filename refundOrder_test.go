@@ -0,0 +1,63 @@
+package binancepay
+
+import (
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestRefundOrder(t *testing.T) {
+	req := &RefundOrderRequest{
+		RefundRequestId: "68711039982968832",
+		PrepayId:        "383729303729303",
+		RefundAmount:    decimal.RequireFromString("0.5"),
+		RefundReason:    "damaged goods",
+	}
+	expectedResp := Response[RefundOrderResult]{
+		Status: "SUCCESS",
+		Code:   "000000",
+		Data: RefundOrderResult{
+			RefundRequestId:   "68711039982968832",
+			PrepayId:          "383729303729303",
+			OrderAmount:       decimal.RequireFromString("1"),
+			RefundedAmount:    decimal.RequireFromString("0.5"),
+			RefundAmount:      decimal.RequireFromString("0.5"),
+			RemainingAttempts: 9,
+			PayerOpenId:       "dde730c2e0ea1f1780cf26343b98fd3b",
+			DuplicateRequest:  "N",
+		},
+	}
+	client := NewMerchant("", "", nil, logger)
+	client.httpClient = mockHttpClientWithAsserts(t, "POST", "/binancepay/openapi/order/refund", req, expectedResp)
+	var resp Response[RefundOrderResult]
+	err := client.Do(req, &resp)
+	assert.Nil(t, err, err)
+	assert.Equal(t, expectedResp.Data.RefundRequestId, resp.Data.RefundRequestId)
+	assert.True(t, expectedResp.Data.RefundedAmount.Equal(resp.Data.RefundedAmount))
+	assert.Equal(t, 9, resp.Data.RemainingAttempts)
+}
+
+func TestRefundOrderValidate(t *testing.T) {
+	client := NewMerchant("", "", nil, logger)
+	var resp Response[RefundOrderResult]
+	err := client.Do(&RefundOrderRequest{PrepayId: "383729303729303"}, &resp)
+	assert.NotNil(t, err)
+}
+
+func TestQueryRefund(t *testing.T) {
+	req := &QueryRefundRequest{RefundRequestId: "68711039982968832"}
+	expectedResp := Response[QueryRefundResult]{
+		Status: "SUCCESS",
+		Code:   "000000",
+		Data: QueryRefundResult{
+			RefundRequestId: "68711039982968832",
+			RefundStatus:    "REFUNDED",
+		},
+	}
+	client := NewMerchant("", "", nil, logger)
+	client.httpClient = mockHttpClientWithAsserts(t, "POST", "/binancepay/openapi/order/refund/query", req, expectedResp)
+	var resp Response[QueryRefundResult]
+	err := client.Do(req, &resp)
+	assert.Nil(t, err, err)
+	assert.Equal(t, "REFUNDED", resp.Data.RefundStatus)
+}
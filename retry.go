@@ -0,0 +1,85 @@
+package binancepay
+
+import (
+	"errors"
+	"math/rand"
+	"time"
+)
+
+// IdempotentRequest is implemented by requests which can safely be sent more than once.
+// Only idempotent requests are retried unless RetryPolicy.RetryNonIdempotent is set.
+type IdempotentRequest interface {
+	Idempotent() bool
+}
+
+// RetryPolicy controls how Merchant.DoContext retries transient failures:
+// transport errors, 5xx and 429 http responses and responses carrying one of RetryableCodes.
+// The zero value disables retries.
+type RetryPolicy struct {
+	MaxAttempts        int           // Total attempts including the first one, values below 2 disable retries.
+	BaseDelay          time.Duration // Delay before the first retry, doubled for every following retry.
+	MaxDelay           time.Duration // Upper bound of the delay, defaultMaxRetryDelay when 0.
+	Jitter             float64       // Fraction of the delay randomized away, in range [0, 1].
+	RetryableCodes     []string      // Response codes worth retrying, see the Code constants.
+	RetryNonIdempotent bool          // Also retry requests which do not implement IdempotentRequest.
+}
+
+// defaultMaxRetryDelay bounds the delay of policies without MaxDelay, doubling it would overflow otherwise.
+const defaultMaxRetryDelay = time.Minute
+
+// DefaultRetryPolicy is a reasonable policy for WithRetryPolicy.
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts:    3,
+	BaseDelay:      200 * time.Millisecond,
+	MaxDelay:       5 * time.Second,
+	Jitter:         0.5,
+	RetryableCodes: []string{CodeUnknownError},
+}
+
+func (p RetryPolicy) attempts(req IRequest) int {
+	if p.MaxAttempts < 2 {
+		return 1
+	}
+	if !p.RetryNonIdempotent {
+		if idempotent, ok := req.(IdempotentRequest); !ok || !idempotent.Idempotent() {
+			return 1
+		}
+	}
+	return p.MaxAttempts
+}
+
+// backoff returns the delay before the retry following the given attempt.
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	maxDelay := p.MaxDelay
+	if maxDelay <= 0 {
+		maxDelay = defaultMaxRetryDelay
+	}
+	delay := p.BaseDelay
+	for i := 1; i < attempt && delay < maxDelay; i++ {
+		delay *= 2
+	}
+	if delay > maxDelay {
+		delay = maxDelay
+	}
+	if p.Jitter > 0 && delay > 0 {
+		jitter := p.Jitter
+		if jitter > 1 {
+			jitter = 1
+		}
+		delay -= time.Duration(rand.Float64() * jitter * float64(delay))
+	}
+	return delay
+}
+
+func (p RetryPolicy) retryable(err error) bool {
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+	for _, code := range p.RetryableCodes {
+		if code == apiErr.Code {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,108 @@
+package binancepay
+
+import (
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+var testRetryPolicy = RetryPolicy{
+	MaxAttempts:    3,
+	BaseDelay:      time.Millisecond,
+	RetryableCodes: []string{CodeUnknownError},
+}
+
+func TestRetryIdempotentRequest(t *testing.T) {
+	var nonces []string
+	client := NewMerchant("", "", nil, logger, WithRetryPolicy(testRetryPolicy))
+	client.httpClient = flakyHttpClient(2, func() (*http.Response, error) {
+		return &http.Response{
+			StatusCode: http.StatusBadGateway,
+			Status:     "502 Bad Gateway",
+			Body:       ioutil.NopCloser(strings.NewReader("bad gateway")),
+		}, nil
+	}, &nonces)
+
+	var resp Response[QueryOrderResult]
+	err := client.Do(&QueryOrderRequest{MerchantTradeNo: "9825382937292"}, &resp)
+	assert.Nil(t, err, err)
+	assert.Equal(t, "123", resp.Data.MerchantId)
+	assert.Len(t, nonces, 3)
+	assert.NotEqual(t, nonces[0], nonces[1], "every attempt must be signed with a fresh nonce")
+}
+
+func TestRetryGivesUpAfterMaxAttempts(t *testing.T) {
+	var nonces []string
+	client := NewMerchant("", "", nil, logger, WithRetryPolicy(testRetryPolicy))
+	client.httpClient = flakyHttpClient(5, func() (*http.Response, error) {
+		return &http.Response{
+			Body: ioutil.NopCloser(strings.NewReader(`{"status":"FAIL","code":"400000","errorMessage":"unknown error"}`)),
+		}, nil
+	}, &nonces)
+
+	var resp Response[CloseOrderResult]
+	err := client.Do(&CloseOrderRequest{MerchantTradeNo: "9825382937292"}, &resp)
+	assert.NotNil(t, err)
+	assert.Len(t, nonces, 3)
+}
+
+func TestRetrySkipsNonRetryableCode(t *testing.T) {
+	var nonces []string
+	client := NewMerchant("", "", nil, logger, WithRetryPolicy(testRetryPolicy))
+	client.httpClient = flakyHttpClient(1, func() (*http.Response, error) {
+		return &http.Response{
+			Body: ioutil.NopCloser(strings.NewReader(`{"status":"FAIL","code":"400202","errorMessage":"order not found"}`)),
+		}, nil
+	}, &nonces)
+
+	var resp Response[QueryOrderResult]
+	err := client.Do(&QueryOrderRequest{MerchantTradeNo: "9825382937292"}, &resp)
+	assert.NotNil(t, err)
+	assert.Len(t, nonces, 1)
+}
+
+func TestRetrySkipsNonIdempotentRequest(t *testing.T) {
+	var nonces []string
+	client := NewMerchant("", "", nil, logger, WithRetryPolicy(testRetryPolicy))
+	client.httpClient = flakyHttpClient(1, func() (*http.Response, error) {
+		return nil, http.ErrHandlerTimeout
+	}, &nonces)
+
+	req := &RefundOrderRequest{
+		RefundRequestId: "68711039982968832",
+		PrepayId:        "383729303729303",
+		RefundAmount:    decimal.RequireFromString("0.5"),
+	}
+	var resp Response[RefundOrderResult]
+	err := client.Do(req, &resp)
+	assert.ErrorIs(t, err, http.ErrHandlerTimeout)
+	assert.Len(t, nonces, 1)
+}
+
+func TestRetryBackoff(t *testing.T) {
+	policy := RetryPolicy{BaseDelay: 100 * time.Millisecond, MaxDelay: time.Second}
+	assert.Equal(t, 100*time.Millisecond, policy.backoff(1))
+	assert.Equal(t, 200*time.Millisecond, policy.backoff(2))
+	assert.Equal(t, 400*time.Millisecond, policy.backoff(3))
+	assert.Equal(t, time.Second, policy.backoff(10))
+
+	policy.Jitter = 0.5
+	for i := 0; i < 100; i++ {
+		delay := policy.backoff(2)
+		assert.True(t, delay > 100*time.Millisecond && delay <= 200*time.Millisecond, delay)
+	}
+}
+
+func TestRetryBackoff_DefaultMaxDelay(t *testing.T) {
+	// doubling an hour 64 times overflows time.Duration without a cap
+	policy := RetryPolicy{BaseDelay: time.Hour}
+	assert.Equal(t, defaultMaxRetryDelay, policy.backoff(64))
+
+	policy = RetryPolicy{BaseDelay: time.Millisecond}
+	assert.Equal(t, 2*time.Millisecond, policy.backoff(2))
+	assert.Equal(t, defaultMaxRetryDelay, policy.backoff(100))
+}
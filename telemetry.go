@@ -0,0 +1,125 @@
+package binancepay
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	metricnoop "go.opentelemetry.io/otel/metric/noop"
+	"go.opentelemetry.io/otel/trace"
+	tracenoop "go.opentelemetry.io/otel/trace/noop"
+	"time"
+)
+
+const instrumentationName = "github.com/dmitrorezn/go-binancepay"
+
+// span names of the webhook verification and the certificate fetch, requests are named after their endpoint
+const (
+	SpanVerifyWebhook     = "binancepay.webhook.verify"
+	SpanFetchCertificates = "binancepay.certificates.fetch"
+)
+
+// metric names, both are recorded with the binancepay.endpoint and binancepay.code attributes
+const (
+	MetricRequestDuration = "binancepay.client.request.duration" // histogram in seconds
+	MetricRequestErrors   = "binancepay.client.request.errors"   // counter of failed requests
+)
+
+// attribute keys of spans and metrics
+const (
+	AttrEndpoint        = attribute.Key("binancepay.endpoint")
+	AttrStatus          = attribute.Key("binancepay.status")
+	AttrCode            = attribute.Key("binancepay.code")
+	AttrHTTPStatus      = attribute.Key("http.response.status_code")
+	AttrAttempts        = attribute.Key("binancepay.attempts")
+	AttrMerchantTradeNo = attribute.Key("binancepay.merchant_trade_no")
+	AttrCertSerial      = attribute.Key("binancepay.certificate_sn")
+	AttrBizType         = attribute.Key("binancepay.biz_type")
+	AttrBizStatus       = attribute.Key("binancepay.biz_status")
+	AttrCertificates    = attribute.Key("binancepay.certificates") // number of fetched certificates
+)
+
+type telemetry struct {
+	tracer   trace.Tracer
+	duration metric.Float64Histogram
+	errors   metric.Int64Counter
+}
+
+func newTelemetry(tp trace.TracerProvider, mp metric.MeterProvider) (*telemetry, error) {
+	if tp == nil {
+		tp = tracenoop.NewTracerProvider()
+	}
+	if mp == nil {
+		mp = metricnoop.NewMeterProvider()
+	}
+	meter := mp.Meter(instrumentationName)
+	duration, err := meter.Float64Histogram(MetricRequestDuration,
+		metric.WithDescription("Duration of binance pay requests including retries."),
+		metric.WithUnit("s"),
+	)
+	if err != nil {
+		return nil, err
+	}
+	errCounter, err := meter.Int64Counter(MetricRequestErrors,
+		metric.WithDescription("Number of failed binance pay requests."),
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &telemetry{
+		tracer:   tp.Tracer(instrumentationName),
+		duration: duration,
+		errors:   errCounter,
+	}, nil
+}
+
+// startRequest starts the span of a Merchant.DoContext call, end records its outcome.
+func (t *telemetry) startRequest(ctx context.Context, endpoint string, body []byte) (context.Context, func(attempts int, err error)) {
+	start := time.Now()
+	attrs := []attribute.KeyValue{AttrEndpoint.String(endpoint)}
+	if tradeNo := merchantTradeNo(body); tradeNo != "" {
+		attrs = append(attrs, AttrMerchantTradeNo.String(tradeNo))
+	}
+	ctx, span := t.tracer.Start(ctx, endpoint, trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(attrs...))
+
+	return ctx, func(attempts int, err error) {
+		status, code := "SUCCESS", CodeSuccess
+		var apiErr *APIError
+		switch {
+		case errors.As(err, &apiErr):
+			status, code = apiErr.Status, apiErr.Code
+			if apiErr.HTTPStatus != 0 {
+				span.SetAttributes(AttrHTTPStatus.Int(apiErr.HTTPStatus))
+			}
+		case err != nil:
+			status, code = "", ""
+		}
+		span.SetAttributes(AttrStatus.String(status), AttrCode.String(code), AttrAttempts.Int(attempts))
+		endSpan(span, err)
+
+		metricAttrs := metric.WithAttributes(AttrEndpoint.String(endpoint), AttrCode.String(code))
+		t.duration.Record(ctx, time.Since(start).Seconds(), metricAttrs)
+		if err != nil {
+			t.errors.Add(ctx, 1, metricAttrs)
+		}
+	}
+}
+
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// merchantTradeNo returns the merchantTradeNo of a request body, if any.
+func merchantTradeNo(body []byte) string {
+	var req struct {
+		MerchantTradeNo string `json:"merchantTradeNo"`
+	}
+	_ = json.Unmarshal(body, &req)
+	return req.MerchantTradeNo
+}
@@ -0,0 +1,140 @@
+package binancepay
+
+import (
+	"bytes"
+	"context"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"io/ioutil"
+	"net/http"
+	"testing"
+)
+
+func newTestTelemetryMerchant(httpClient *http.Client, opts ...Option) (*Merchant, *tracetest.InMemoryExporter, *sdkmetric.ManualReader) {
+	exporter := tracetest.NewInMemoryExporter()
+	reader := sdkmetric.NewManualReader()
+	opts = append([]Option{
+		WithHTTPClient(httpClient),
+		WithTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))),
+		WithMeterProvider(sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))),
+	}, opts...)
+	return NewMerchant("key", "secret", nil, logger, opts...), exporter, reader
+}
+
+func spanAttributes(span tracetest.SpanStub) map[attribute.Key]attribute.Value {
+	attrs := map[attribute.Key]attribute.Value{}
+	for _, kv := range span.Attributes {
+		attrs[kv.Key] = kv.Value
+	}
+	return attrs
+}
+
+func TestTelemetry_Do(t *testing.T) {
+	var calls int
+	httpClient := mockHttpClient(func(request *http.Request) (*http.Response, error) {
+		calls++
+		body := `{"status":"SUCCESS","code":"000000","data":{"status":"PAID"}}`
+		if calls == 2 {
+			body = `{"status":"FAIL","code":"400202","errorMessage":"order not found"}`
+		}
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       ioutil.NopCloser(bytes.NewBufferString(body)),
+		}, nil
+	})
+	m, exporter, reader := newTestTelemetryMerchant(httpClient)
+
+	err := m.Do(&QueryOrderRequest{MerchantTradeNo: "9825382937292"}, &Response[QueryOrderResult]{})
+	assert.Nil(t, err, err)
+	err = m.Do(&QueryOrderRequest{MerchantTradeNo: "missing"}, &Response[QueryOrderResult]{})
+	assert.ErrorIs(t, err, ErrOrderNotFound)
+
+	spans := exporter.GetSpans()
+	assert.Len(t, spans, 2)
+	assert.Equal(t, "/binancepay/openapi/v2/order/query", spans[0].Name)
+	attrs := spanAttributes(spans[0])
+	assert.Equal(t, "9825382937292", attrs[AttrMerchantTradeNo].AsString())
+	assert.Equal(t, "SUCCESS", attrs[AttrStatus].AsString())
+	assert.Equal(t, CodeSuccess, attrs[AttrCode].AsString())
+	assert.Equal(t, int64(1), attrs[AttrAttempts].AsInt64())
+	assert.Equal(t, codes.Unset, spans[0].Status.Code)
+
+	attrs = spanAttributes(spans[1])
+	assert.Equal(t, "missing", attrs[AttrMerchantTradeNo].AsString())
+	assert.Equal(t, "FAIL", attrs[AttrStatus].AsString())
+	assert.Equal(t, CodeOrderNotFound, attrs[AttrCode].AsString())
+	assert.Equal(t, codes.Error, spans[1].Status.Code)
+
+	var rm metricdata.ResourceMetrics
+	err = reader.Collect(context.Background(), &rm)
+	assert.Nil(t, err, err)
+	metrics := map[string]metricdata.Metrics{}
+	for _, sm := range rm.ScopeMetrics {
+		for _, metric := range sm.Metrics {
+			metrics[metric.Name] = metric
+		}
+	}
+
+	duration, ok := metrics[MetricRequestDuration].Data.(metricdata.Histogram[float64])
+	assert.True(t, ok)
+	assert.Len(t, duration.DataPoints, 2)
+	for _, dp := range duration.DataPoints {
+		endpoint, _ := dp.Attributes.Value(AttrEndpoint)
+		assert.Equal(t, "/binancepay/openapi/v2/order/query", endpoint.AsString())
+		assert.Equal(t, uint64(1), dp.Count)
+	}
+
+	errCounter, ok := metrics[MetricRequestErrors].Data.(metricdata.Sum[int64])
+	assert.True(t, ok)
+	assert.Len(t, errCounter.DataPoints, 1)
+	code, _ := errCounter.DataPoints[0].Attributes.Value(AttrCode)
+	assert.Equal(t, CodeOrderNotFound, code.AsString())
+	assert.Equal(t, int64(1), errCounter.DataPoints[0].Value)
+}
+
+func TestTelemetry_VerifyWebhook(t *testing.T) {
+	priv, cert := newTestCertificate(t, "serial-1")
+	certs := []Certificate{cert}
+	fetches := 0
+	m, exporter, _ := newTestTelemetryMerchant(certificatesHttpClient(t, &fetches, &certs))
+
+	_, err := m.VerifyAndParseWebhookRequest(newWebhookRequestSignedBy(t, priv, "serial-1", testOrderWebhookBody))
+	assert.Nil(t, err, err)
+	assert.Equal(t, 1, fetches)
+
+	spans := exporter.GetSpans()
+	byName := map[string]tracetest.SpanStub{}
+	for _, span := range spans {
+		byName[span.Name] = span
+	}
+	verify, ok := byName[SpanVerifyWebhook]
+	assert.True(t, ok)
+	fetch, ok := byName[SpanFetchCertificates]
+	assert.True(t, ok)
+	query, ok := byName["/binancepay/openapi/certificates"]
+	assert.True(t, ok)
+
+	assert.Equal(t, verify.SpanContext.SpanID(), fetch.Parent.SpanID())
+	assert.Equal(t, fetch.SpanContext.SpanID(), query.Parent.SpanID())
+
+	attrs := spanAttributes(verify)
+	assert.Equal(t, "serial-1", attrs[AttrCertSerial].AsString())
+	assert.Equal(t, "PAY", attrs[AttrBizType].AsString())
+	assert.Equal(t, "PAY_SUCCESS", attrs[AttrBizStatus].AsString())
+	assert.Equal(t, int64(1), spanAttributes(fetch)[AttrCertificates].AsInt64())
+
+	// forged webhooks end the span with an error
+	exporter.Reset()
+	forged := newWebhookRequestSignedBy(t, priv, "serial-1", testOrderWebhookBody)
+	forged.Body = ioutil.NopCloser(bytes.NewBufferString(testOrderWebhookBody + " "))
+	_, err = m.VerifyAndParseWebhookRequest(forged)
+	assert.NotNil(t, err)
+	spans = exporter.GetSpans()
+	assert.Equal(t, SpanVerifyWebhook, spans[len(spans)-1].Name)
+	assert.Equal(t, codes.Error, spans[len(spans)-1].Status.Code)
+}
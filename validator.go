@@ -4,6 +4,147 @@
 
 package binancepay
 
-import "github.com/go-playground/validator/v10"
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/go-playground/validator/v10"
+	"github.com/shopspring/decimal"
+	"reflect"
+	"strings"
+	"time"
+)
 
-var validate = validator.New()
+var validate = newValidator()
+
+func newValidator() *validator.Validate {
+	v := validator.New()
+	// errors name fields like the JSON sent to binance pay
+	v.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name := strings.SplitN(field.Tag.Get("json"), ",", 2)[0]
+		if name == "-" {
+			return ""
+		}
+		return name
+	})
+	v.RegisterCustomTypeFunc(validateDecimal, decimal.Decimal{})
+	_ = v.RegisterValidation("precision", validatePrecision)
+	_ = v.RegisterValidation("order_amount", validateOrderAmount)
+	_ = v.RegisterValidation("goods_category", validateGoodsCategory)
+	_ = v.RegisterValidationCtx("future_millis", validateFutureMillis)
+	_ = v.RegisterValidation("currency_list", validateCurrencyList)
+	v.RegisterStructValidation(validateCreateOrderV2Request, CreateOrderV2Request{})
+	v.RegisterStructValidation(validateBatchPayoutRequest, BatchPayoutRequest{})
+	return v
+}
+
+// FieldError describes an invalid field of a request.
+type FieldError struct {
+	Field   string // JSON path of the field, e.g. "goods.goodsCategory"
+	Rule    string // failed rule, e.g. "required" or "max"
+	Param   string // parameter of the rule, e.g. "32" for max=32
+	Value   interface{}
+	Message string // e.g. "goods.goodsCategory must be one of the documented goods categories"
+}
+
+func (e FieldError) Error() string {
+	return e.Message
+}
+
+// ValidationError is returned by the Validate methods of requests. errors.As still finds the
+// validator.ValidationErrors it was built from.
+type ValidationError struct {
+	Fields []FieldError
+
+	cause validator.ValidationErrors
+}
+
+func (e *ValidationError) Error() string {
+	messages := make([]string, 0, len(e.Fields))
+	for _, field := range e.Fields {
+		messages = append(messages, field.Message)
+	}
+	return "invalid request: " + strings.Join(messages, "; ")
+}
+
+func (e *ValidationError) Unwrap() error {
+	return e.cause
+}
+
+type validationTimeKey struct{}
+
+// validationTime returns the time rules like future_millis are checked against.
+func validationTime(ctx context.Context) time.Time {
+	if now, ok := ctx.Value(validationTimeKey{}).(time.Time); ok {
+		return now
+	}
+	return time.Now()
+}
+
+// validateStruct validates s at the current time, failed rules are returned as *ValidationError.
+func validateStruct(s interface{}) error {
+	return validateStructAt(s, time.Now())
+}
+
+// validateStructAt validates s, checking time rules against now.
+func validateStructAt(s interface{}, now time.Time) error {
+	err := validate.StructCtx(context.WithValue(context.Background(), validationTimeKey{}, now), s)
+	var validationErrs validator.ValidationErrors
+	if !errors.As(err, &validationErrs) {
+		return err
+	}
+	fields := make([]FieldError, 0, len(validationErrs))
+	for _, fieldErr := range validationErrs {
+		field := fieldErr.Namespace()
+		// strip the name of the validated struct
+		if i := strings.IndexByte(field, '.'); i >= 0 {
+			field = field[i+1:]
+		}
+		fields = append(fields, FieldError{
+			Field:   field,
+			Rule:    fieldErr.Tag(),
+			Param:   fieldErr.Param(),
+			Value:   fieldErr.Value(),
+			Message: field + " " + ruleMessage(fieldErr.Tag(), fieldErr.Param()),
+		})
+	}
+	return &ValidationError{Fields: fields, cause: validationErrs}
+}
+
+func ruleMessage(rule, param string) string {
+	switch rule {
+	case "required":
+		return "is required"
+	case "required_goods":
+		return "or goodsDetails is required"
+	case "required_mini_program":
+		return "is required for the MINI_PROGRAM terminal type"
+	case "max":
+		return fmt.Sprintf("must be at most %s long", param)
+	case "min":
+		return fmt.Sprintf("must be at least %s long", param)
+	case "oneof":
+		return "must be one of " + param
+	case "alphanum":
+		return "must contain only letters and digits"
+	case "number":
+		return "must be a number"
+	case "url":
+		return "must be a URL"
+	case "email":
+		return "must be an email address"
+	case "iso3166_1_alpha2":
+		return "must be a two letter country code"
+	case "precision":
+		return "has more decimal places than its currency accepts"
+	case "order_amount":
+		return fmt.Sprintf("must be between %s and %s", MinOrderAmount, MaxOrderAmount)
+	case "goods_category":
+		return "must be one of the documented goods categories"
+	case "future_millis":
+		return "must be in the future"
+	case "currency_list":
+		return `must be a comma separated list of currencies, e.g. "BUSD,BNB"`
+	}
+	return fmt.Sprintf("failed on the %s rule", rule)
+}
@@ -0,0 +1,215 @@
+package binancepay
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go.uber.org/zap"
+	"net/http"
+)
+
+// DefaultWebhookMaxBodyBytes is the default limit of webhook request bodies accepted by WebhookHandler.
+const DefaultWebhookMaxBodyBytes = 1 << 20
+
+type (
+	OrderNotiHandler    func(ctx context.Context, noti *OrderNoti, bizStatus BizStatus) error
+	RefundNotiHandler   func(ctx context.Context, noti *RefundOrderNoti, bizStatus BizStatus) error
+	PayoutNotiHandler   func(ctx context.Context, noti *PayoutNoti, bizStatus BizStatus) error
+	FallbackNotiHandler func(ctx context.Context, bizType NotiBizType, bizStatus BizStatus, rawData string) error
+)
+
+var _ http.Handler = &WebhookHandler{}
+
+// WebhookHandler verifies binance pay webhooks, dispatches them to the registered callbacks by bizType
+// and writes the response expected by binance pay.
+//
+// A callback returning an error makes the handler respond FAIL, so binance pay delivers the notification again.
+// Notifications without a registered callback and without a fallback are acknowledged with SUCCESS,
+// so are replayed notifications once the first delivery was processed, see WithWebhookNonceStore.
+type WebhookHandler struct {
+	merchant     *Merchant
+	maxBodyBytes int64
+
+	onOrder    OrderNotiHandler
+	onRefund   RefundNotiHandler
+	onPayout   PayoutNotiHandler
+	onFallback FallbackNotiHandler
+}
+
+func NewWebhookHandler(m *Merchant) *WebhookHandler {
+	return &WebhookHandler{
+		merchant:     m,
+		maxBodyBytes: DefaultWebhookMaxBodyBytes,
+	}
+}
+
+// OnOrder registers the callback of NotiBizTypeOrder notifications.
+func (h *WebhookHandler) OnOrder(fn OrderNotiHandler) *WebhookHandler {
+	h.onOrder = fn
+	return h
+}
+
+// OnRefund registers the callback of NotiBizTypePayRefund notifications.
+func (h *WebhookHandler) OnRefund(fn RefundNotiHandler) *WebhookHandler {
+	h.onRefund = fn
+	return h
+}
+
+// OnPayout registers the callback of NotiBizTypePayout notifications.
+func (h *WebhookHandler) OnPayout(fn PayoutNotiHandler) *WebhookHandler {
+	h.onPayout = fn
+	return h
+}
+
+// OnFallback registers the callback of notifications without a dedicated callback.
+func (h *WebhookHandler) OnFallback(fn FallbackNotiHandler) *WebhookHandler {
+	h.onFallback = fn
+	return h
+}
+
+// MaxBodyBytes overrides DefaultWebhookMaxBodyBytes.
+func (h *WebhookHandler) MaxBodyBytes(n int64) *WebhookHandler {
+	h.maxBodyBytes = n
+	return h
+}
+
+func (h *WebhookHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		h.respond(w, http.StatusMethodNotAllowed, false, "method not allowed")
+		return
+	}
+
+	if h.maxBodyBytes > 0 {
+		r.Body = http.MaxBytesReader(w, r.Body, h.maxBodyBytes)
+	}
+
+	ctx := r.Context()
+	rawReq, err := h.merchant.VerifyAndParseWebhookRequestContext(ctx, r)
+	if err != nil {
+		h.merchant.logger.Warn("failed to verify webhook request", zap.Error(err))
+
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			h.respond(w, http.StatusRequestEntityTooLarge, false, "request body too large")
+			return
+		}
+		var unavailableErr *webhookUnavailableError
+		if errors.As(err, &unavailableErr) {
+			h.respond(w, http.StatusServiceUnavailable, false, "webhook verification unavailable")
+			return
+		}
+		if errors.Is(err, ErrWebhookReplayed) {
+			// the notification was processed already, binance pay must stop delivering it
+			h.respond(w, http.StatusOK, true, "")
+			return
+		}
+		if errors.Is(err, ErrWebhookInProgress) {
+			// the first delivery may still fail, binance pay must deliver the notification again
+			h.respond(w, http.StatusConflict, false, "webhook is being processed")
+			return
+		}
+		h.respond(w, http.StatusUnauthorized, false, "invalid webhook request")
+		return
+	}
+
+	handled, err := h.dispatch(ctx, rawReq)
+	if err != nil {
+		h.merchant.logger.Error("failed to handle webhook request",
+			zap.String("bizType", string(rawReq.BizType)),
+			zap.String("bizId", rawReq.BizId.String()),
+			zap.Error(err),
+		)
+		// accept the redelivery of the notification
+		if releaseErr := h.merchant.ReleaseWebhookNonce(context.WithoutCancel(ctx), rawReq.nonce); releaseErr != nil {
+			h.merchant.logger.Error("failed to release webhook nonce", zap.Error(releaseErr))
+		}
+
+		var parseErr *webhookDataError
+		if errors.As(err, &parseErr) {
+			h.respond(w, http.StatusBadRequest, false, "invalid webhook data")
+			return
+		}
+		h.respond(w, http.StatusInternalServerError, false, "failed to process webhook")
+		return
+	}
+	if err = h.merchant.CompleteWebhookNonce(context.WithoutCancel(ctx), rawReq.nonce); err != nil {
+		// the redeliveries are answered as in progress until the nonce expires
+		h.merchant.logger.Error("failed to complete webhook nonce", zap.Error(err))
+	}
+	if !handled {
+		h.merchant.logger.Debug("unhandled webhook request",
+			zap.String("bizType", string(rawReq.BizType)),
+			zap.String("bizId", rawReq.BizId.String()),
+		)
+	}
+
+	h.respond(w, http.StatusOK, true, "")
+}
+
+// webhookUnavailableError is a failure of the certificates or the nonce store rather than of the webhook request,
+// binance pay should deliver the webhook again.
+type webhookUnavailableError struct {
+	err error
+}
+
+func (e *webhookUnavailableError) Error() string {
+	return e.err.Error()
+}
+
+func (e *webhookUnavailableError) Unwrap() error {
+	return e.err
+}
+
+type webhookDataError struct {
+	err error
+}
+
+func (e *webhookDataError) Error() string {
+	return fmt.Sprintf("json.Unmarshal(data): %s", e.err)
+}
+
+func (e *webhookDataError) Unwrap() error {
+	return e.err
+}
+
+func decodeWebhookData(rawData string, v interface{}) error {
+	if err := json.Unmarshal([]byte(rawData), v); err != nil {
+		return &webhookDataError{err: err}
+	}
+	return nil
+}
+
+func (h *WebhookHandler) dispatch(ctx context.Context, rawReq *webhookRawReq) (handled bool, err error) {
+	switch {
+	case rawReq.BizType == NotiBizTypeOrder && h.onOrder != nil:
+		var noti OrderNoti
+		if err = decodeWebhookData(rawReq.RawData, &noti); err != nil {
+			return false, err
+		}
+		return true, h.onOrder(ctx, &noti, rawReq.BizStatus)
+	case rawReq.BizType == NotiBizTypePayRefund && h.onRefund != nil:
+		var noti RefundOrderNoti
+		if err = decodeWebhookData(rawReq.RawData, &noti); err != nil {
+			return false, err
+		}
+		return true, h.onRefund(ctx, &noti, rawReq.BizStatus)
+	case rawReq.BizType == NotiBizTypePayout && h.onPayout != nil:
+		var noti PayoutNoti
+		if err = decodeWebhookData(rawReq.RawData, &noti); err != nil {
+			return false, err
+		}
+		return true, h.onPayout(ctx, &noti, rawReq.BizStatus)
+	case h.onFallback != nil:
+		return true, h.onFallback(ctx, rawReq.BizType, rawReq.BizStatus, rawReq.RawData)
+	}
+	return false, nil
+}
+
+func (h *WebhookHandler) respond(w http.ResponseWriter, statusCode int, success bool, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	if err := h.merchant.WebhookResponse(w, success, message); err != nil {
+		h.merchant.logger.Error("failed to write webhook response", zap.Error(err))
+	}
+}
@@ -0,0 +1,121 @@
+package binancepay
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func newSignedWebhookRequest(t *testing.T, body string) *http.Request {
+	httpReq, err := http.NewRequest("POST", "/webhook", strings.NewReader(body))
+	assert.Nil(t, err, err)
+	timestamp := "1654943252000"
+	nonce := "abc"
+	httpReq.Header.Set("BinancePay-Certificate-SN", "abc")
+	httpReq.Header.Set("BinancePay-Nonce", nonce)
+	httpReq.Header.Set("BinancePay-Timestamp", timestamp)
+	httpReq.Header.Set("BinancePay-Signature", generateSignature([]byte(BuildPayload(body, timestamp, nonce))))
+	return httpReq
+}
+
+func newTestWebhookHandler() *WebhookHandler {
+	client := NewMerchant("", "", fixedPublicKeyTestCache{exist: true, publicKey: testDataPublicKey}, logger)
+	return NewWebhookHandler(client)
+}
+
+const testOrderWebhookBody = `{"bizType":"PAY","data":"{\"merchantTradeNo\":\"9825382937292\",\"totalFee\":0.88000000,\"transactTime\":1619508939664,\"currency\":\"USDT\",\"openUserId\":\"1211HS10K81f4273ac031\",\"productType\":\"Food\",\"productName\":\"Ice Cream\",\"tradeType\":\"WEB\",\"transactionId\":\"M_R_282737362839373\"}","bizId":29383937493038367292,"bizStatus":"PAY_SUCCESS"}`
+
+func TestWebhookHandlerOrder(t *testing.T) {
+	var got *OrderNoti
+	var gotStatus BizStatus
+	handler := newTestWebhookHandler().
+		OnOrder(func(ctx context.Context, noti *OrderNoti, bizStatus BizStatus) error {
+			got = noti
+			gotStatus = bizStatus
+			return nil
+		}).
+		OnRefund(func(ctx context.Context, noti *RefundOrderNoti, bizStatus BizStatus) error {
+			t.Fatal("should never reach")
+			return nil
+		})
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, newSignedWebhookRequest(t, testOrderWebhookBody))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, `{"returnCode":"SUCCESS","returnMessage":null}`, w.Body.String())
+	assert.Equal(t, "9825382937292", got.MerchantTradeNo)
+	assert.Equal(t, 0.88, got.TotalFee.InexactFloat64())
+	assert.Equal(t, BizStatusPaySuccess, gotStatus)
+}
+
+func TestWebhookHandlerCallbackError(t *testing.T) {
+	handler := newTestWebhookHandler().
+		OnOrder(func(ctx context.Context, noti *OrderNoti, bizStatus BizStatus) error {
+			return errors.New("database unavailable")
+		})
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, newSignedWebhookRequest(t, testOrderWebhookBody))
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.Equal(t, `{"returnCode":"FAIL","returnMessage":"failed to process webhook"}`, w.Body.String())
+}
+
+func TestWebhookHandlerFallback(t *testing.T) {
+	var gotType NotiBizType
+	handler := newTestWebhookHandler().
+		OnFallback(func(ctx context.Context, bizType NotiBizType, bizStatus BizStatus, rawData string) error {
+			gotType = bizType
+			return nil
+		})
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, newSignedWebhookRequest(t, testOrderWebhookBody))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, NotiBizTypeOrder, gotType)
+}
+
+func TestWebhookHandlerRejectsRequests(t *testing.T) {
+	handler := newTestWebhookHandler()
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/webhook", nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+
+	w = httptest.NewRecorder()
+	invalid := newSignedWebhookRequest(t, testOrderWebhookBody)
+	invalid.Header.Set("BinancePay-Nonce", "other")
+	handler.ServeHTTP(w, invalid)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Equal(t, `{"returnCode":"FAIL","returnMessage":"invalid webhook request"}`, w.Body.String())
+
+	w = httptest.NewRecorder()
+	handler.MaxBodyBytes(16).ServeHTTP(w, newSignedWebhookRequest(t, testOrderWebhookBody))
+	assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+}
+
+func TestWebhookHandlerInvalidData(t *testing.T) {
+	handler := newTestWebhookHandler().
+		OnPayout(func(ctx context.Context, noti *PayoutNoti, bizStatus BizStatus) error {
+			return nil
+		})
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, newSignedWebhookRequest(t, `{"bizType":"PAYOUT","data":"not json","bizId":1,"bizStatus":"SUCCESS"}`))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestWebhookHandlerCertificatesUnavailable(t *testing.T) {
+	client := NewMerchant("", "", nil, logger)
+	client.httpClient = mockHttpClient(func(request *http.Request) (*http.Response, error) {
+		return nil, errors.New("connection refused")
+	})
+
+	w := httptest.NewRecorder()
+	NewWebhookHandler(client).ServeHTTP(w, newSignedWebhookRequest(t, testOrderWebhookBody))
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.Equal(t, `{"returnCode":"FAIL","returnMessage":"webhook verification unavailable"}`, w.Body.String())
+}
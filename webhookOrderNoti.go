@@ -21,6 +21,11 @@ type OrderNoti struct {
 	PayerInfo       *OrderNotiPayerInfo `json:"payerInfo,omitempty"`
 }
 
+// Money returns the TotalFee in Currency.
+func (n *OrderNoti) Money() Money {
+	return NewMoney(n.TotalFee, n.Currency)
+}
+
 type OrderNotiPayerInfo struct {
 	FirstName      string `json:"firstName"`
 	MiddleName     string `json:"middleName"`
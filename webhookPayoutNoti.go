@@ -14,3 +14,8 @@ type PayoutNoti struct {
 	TotalAmount string `json:"totalAmount"`
 	TotalNumber string `json:"totalNumber"`
 }
+
+// Money returns the TotalAmount in Currency, an empty TotalAmount is zero.
+func (n *PayoutNoti) Money() (Money, error) {
+	return moneyOf(n.TotalAmount, n.Currency)
+}
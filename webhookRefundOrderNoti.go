@@ -4,15 +4,64 @@
 
 package binancepay
 
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/shopspring/decimal"
+)
+
 const NotiBizTypePayRefund NotiBizType = "PAY_REFUND"
 
 type RefundOrderNoti struct {
-	MerchantTradeNo string `json:"merchantTradeNo"`
-	ProductType     string `json:"productType"`
-	ProductName     string `json:"productName"`
-	TradeType       string `json:"tradeType"`
-	TotalFee        string `json:"totalFee"`
-	Currency        string `json:"currency"`
-	OpenUserId      string `json:"openUserId"`
-	RefundInfo      string `json:"refundInfo"`
+	MerchantTradeNo string     `json:"merchantTradeNo"`
+	ProductType     string     `json:"productType"`
+	ProductName     string     `json:"productName"`
+	TradeType       string     `json:"tradeType"`
+	TotalFee        string     `json:"totalFee"`
+	Currency        string     `json:"currency"`
+	OpenUserId      string     `json:"openUserId"`
+	RefundInfo      RefundInfo `json:"refundInfo"`
+}
+
+type RefundInfo struct {
+	RefundRequestId   string          `json:"refundRequestId"`
+	PrepayId          string          `json:"prepayId"`
+	OrderAmount       decimal.Decimal `json:"orderAmount"`
+	RefundedAmount    decimal.Decimal `json:"refundedAmount"`
+	RefundAmount      decimal.Decimal `json:"refundAmount"`
+	RemainingAttempts int             `json:"remainingAttempts"`
+	PayerOpenId       string          `json:"payerOpenId"`
+	DuplicateRequest  string          `json:"duplicateRequest"`
+}
+
+// Money returns the TotalFee in Currency, an empty TotalFee is zero.
+func (n *RefundOrderNoti) Money() (Money, error) {
+	return moneyOf(n.TotalFee, n.Currency)
+}
+
+// Money returns the RefundAmount in currency, the currency of the refunded order.
+func (r *RefundInfo) Money(currency string) Money {
+	return NewMoney(r.RefundAmount, currency)
+}
+
+// UnmarshalJSON accepts refundInfo both as a JSON object and as a JSON encoded string,
+// binance has been sending both forms.
+func (r *RefundInfo) UnmarshalJSON(data []byte) error {
+	type refundInfo RefundInfo
+
+	var raw string
+	if err := json.Unmarshal(data, &raw); err == nil {
+		if raw == "" {
+			*r = RefundInfo{}
+			return nil
+		}
+		data = []byte(raw)
+	}
+
+	var info refundInfo
+	if err := json.Unmarshal(data, &info); err != nil {
+		return fmt.Errorf("json.Unmarshal(refundInfo): %w", err)
+	}
+	*r = RefundInfo(info)
+	return nil
 }
@@ -0,0 +1,29 @@
+package binancepay
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestUnmarshalRefundOrderNoti(t *testing.T) {
+	object := `{"merchantTradeNo":"6177e6adbd8b4e9b9fe3b9e5b6e1c5b7","productType":"Food","productName":"Ice Cream","tradeType":"WEB","totalFee":"1.00000000","currency":"USDT","openUserId":"","refundInfo":{"orderAmount":"1.00000000","refundRequestId":"68711039982968832","refundedAmount":"0.50000000","refundAmount":"0.50000000","remainingAttempts":9,"payerOpenId":"dde730c2e0ea1f1780cf26343b98fd3b","duplicateRequest":"N"}}`
+	encoded := `{"merchantTradeNo":"6177e6adbd8b4e9b9fe3b9e5b6e1c5b7","productType":"Food","productName":"Ice Cream","tradeType":"WEB","totalFee":"1.00000000","currency":"USDT","openUserId":"","refundInfo":"{\"orderAmount\":\"1.00000000\",\"refundRequestId\":\"68711039982968832\",\"refundedAmount\":\"0.50000000\",\"refundAmount\":\"0.50000000\",\"remainingAttempts\":9,\"payerOpenId\":\"dde730c2e0ea1f1780cf26343b98fd3b\",\"duplicateRequest\":\"N\"}"}`
+
+	for _, body := range []string{object, encoded} {
+		var noti RefundOrderNoti
+		err := json.Unmarshal([]byte(body), &noti)
+		assert.Nil(t, err, err)
+		assert.Equal(t, "6177e6adbd8b4e9b9fe3b9e5b6e1c5b7", noti.MerchantTradeNo)
+		assert.Equal(t, "68711039982968832", noti.RefundInfo.RefundRequestId)
+		assert.Equal(t, 0.5, noti.RefundInfo.RefundAmount.InexactFloat64())
+		assert.Equal(t, 1.0, noti.RefundInfo.OrderAmount.InexactFloat64())
+		assert.Equal(t, 9, noti.RefundInfo.RemainingAttempts)
+	}
+}
+
+func TestUnmarshalRefundOrderNotiInvalidRefundInfo(t *testing.T) {
+	var noti RefundOrderNoti
+	err := json.Unmarshal([]byte(`{"refundInfo":"not json"}`), &noti)
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,163 @@
+package binancepay
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+const (
+	// DefaultWebhookNonceTTL is how long nonces are remembered when no acceptance window is configured.
+	DefaultWebhookNonceTTL = 24 * time.Hour
+
+	releasedNonceTTL = time.Minute
+	// a nonce stays in progress at most for processingNonceTTL, so a crashed delivery doesn't block the redeliveries
+	processingNonceTTL = 5 * time.Minute
+)
+
+var (
+	// ErrWebhookExpired is returned when the Binancepay-Timestamp of a webhook is outside the acceptance window.
+	ErrWebhookExpired = errors.New("webhook timestamp outside of acceptance window")
+	// ErrWebhookReplayed is returned when the webhook with the same Binancepay-Nonce was already processed.
+	ErrWebhookReplayed = errors.New("webhook nonce already seen")
+	// ErrWebhookInProgress is returned when the webhook with the same Binancepay-Nonce is still being processed.
+	ErrWebhookInProgress = errors.New("webhook nonce is being processed")
+)
+
+// NonceState is the state of a webhook nonce in a NonceStore.
+type NonceState string
+
+const (
+	NonceUnseen     NonceState = ""           // not recorded or released
+	NonceProcessing NonceState = "processing" // the webhook is being processed
+	NonceProcessed  NonceState = "processed"  // the webhook was processed
+)
+
+// NonceStore remembers the nonces of verified webhooks, see WithWebhookNonceStore.
+type NonceStore interface {
+	// SeenNonce records an unseen nonce as NonceProcessing for ttl and returns the state the nonce had before.
+	SeenNonce(ctx context.Context, nonce string, ttl time.Duration) (NonceState, error)
+	// CompleteNonce records nonce as NonceProcessed for ttl.
+	CompleteNonce(ctx context.Context, nonce string, ttl time.Duration) error
+	// ReleaseNonce forgets a recorded nonce, so the webhook is accepted when binance pay delivers it again.
+	ReleaseNonce(ctx context.Context, nonce string) error
+}
+
+type cacheNonceStore struct {
+	cache Cache
+}
+
+// NewCacheNonceStore returns a NonceStore backed by cache.
+// The check and the write are not atomic, concurrent deliveries of the same nonce may both pass.
+func NewCacheNonceStore(cache Cache) NonceStore {
+	return &cacheNonceStore{cache: cache}
+}
+
+func cacheNonceKey(nonce string) string {
+	return "binance-pay:webhook-nonce:" + nonce
+}
+
+func (s *cacheNonceStore) SeenNonce(ctx context.Context, nonce string, ttl time.Duration) (NonceState, error) {
+	key := cacheNonceKey(nonce)
+
+	var state NonceState
+	exists, err := s.cache.GetJSON(ctx, key, &state)
+	if err != nil {
+		return NonceUnseen, fmt.Errorf("cache.GetJSON(): %w", err)
+	}
+	if exists && state != NonceUnseen {
+		return state, nil
+	}
+	if err = s.cache.SetJSON(ctx, key, NonceProcessing, ttl); err != nil {
+		return NonceUnseen, fmt.Errorf("cache.SetJSON(): %w", err)
+	}
+	return NonceUnseen, nil
+}
+
+func (s *cacheNonceStore) CompleteNonce(ctx context.Context, nonce string, ttl time.Duration) error {
+	if err := s.cache.SetJSON(ctx, cacheNonceKey(nonce), NonceProcessed, ttl); err != nil {
+		return fmt.Errorf("cache.SetJSON(): %w", err)
+	}
+	return nil
+}
+
+// ReleaseNonce overwrites the nonce, Cache can't delete keys.
+func (s *cacheNonceStore) ReleaseNonce(ctx context.Context, nonce string) error {
+	if err := s.cache.SetJSON(ctx, cacheNonceKey(nonce), NonceUnseen, releasedNonceTTL); err != nil {
+		return fmt.Errorf("cache.SetJSON(): %w", err)
+	}
+	return nil
+}
+
+// checkWebhookTimestamp rejects webhooks signed outside of the acceptance window around now.
+func (m *Merchant) checkWebhookTimestamp(timestamp string) error {
+	if m.webhookWindow <= 0 {
+		return nil
+	}
+	millis, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return fmt.Errorf("parseTimestamp(): %w", err)
+	}
+	diff := m.now().Sub(time.UnixMilli(millis))
+	if diff > m.webhookWindow || diff < -m.webhookWindow {
+		return fmt.Errorf("timestamp=%s: %w", timestamp, ErrWebhookExpired)
+	}
+	return nil
+}
+
+// webhookNonceTTL is how long processed nonces are remembered.
+func (m *Merchant) webhookNonceTTL() time.Duration {
+	if m.webhookWindow > 0 {
+		// a nonce older than the window is rejected by its timestamp already
+		return 2 * m.webhookWindow
+	}
+	return DefaultWebhookNonceTTL
+}
+
+// checkWebhookNonce rejects webhooks whose nonce was already seen.
+func (m *Merchant) checkWebhookNonce(ctx context.Context, nonce string) error {
+	if m.nonceStore == nil {
+		return nil
+	}
+	ttl := m.webhookNonceTTL()
+	if ttl > processingNonceTTL {
+		ttl = processingNonceTTL
+	}
+	state, err := m.nonceStore.SeenNonce(ctx, nonce, ttl)
+	if err != nil {
+		return &webhookUnavailableError{err: fmt.Errorf("nonceStore.SeenNonce(): %w", err)}
+	}
+	switch state {
+	case NonceUnseen:
+		return nil
+	case NonceProcessing:
+		return fmt.Errorf("nonce=%s: %w", nonce, ErrWebhookInProgress)
+	}
+	return fmt.Errorf("nonce=%s: %w", nonce, ErrWebhookReplayed)
+}
+
+// CompleteWebhookNonce records the Binancepay-Nonce of a verified webhook as processed, so its redeliveries
+// are rejected with ErrWebhookReplayed rather than ErrWebhookInProgress. WebhookHandler completes nonces itself.
+func (m *Merchant) CompleteWebhookNonce(ctx context.Context, nonce string) error {
+	if m.nonceStore == nil {
+		return nil
+	}
+	if err := m.nonceStore.CompleteNonce(ctx, nonce, m.webhookNonceTTL()); err != nil {
+		return fmt.Errorf("nonceStore.CompleteNonce(): %w", err)
+	}
+	return nil
+}
+
+// ReleaseWebhookNonce forgets the Binancepay-Nonce of a verified webhook which couldn't be processed,
+// so the redelivery of binance pay isn't rejected as replayed. WebhookHandler releases nonces itself.
+func (m *Merchant) ReleaseWebhookNonce(ctx context.Context, nonce string) error {
+	if m.nonceStore == nil {
+		return nil
+	}
+	if err := m.nonceStore.ReleaseNonce(ctx, nonce); err != nil {
+		return fmt.Errorf("nonceStore.ReleaseNonce(): %w", err)
+	}
+	return nil
+}
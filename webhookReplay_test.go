@@ -0,0 +1,149 @@
+package binancepay
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestWebhookTimestampWindow(t *testing.T) {
+	signedAt := time.UnixMilli(1654943252000)
+	for _, tc := range []struct {
+		now   time.Time
+		valid bool
+	}{
+		{now: signedAt, valid: true},
+		{now: signedAt.Add(4 * time.Minute), valid: true},
+		{now: signedAt.Add(-4 * time.Minute), valid: true},
+		{now: signedAt.Add(6 * time.Minute), valid: false},
+		{now: signedAt.Add(-6 * time.Minute), valid: false},
+	} {
+		now := tc.now
+		client := NewMerchant("", "", fixedPublicKeyTestCache{exist: true, publicKey: testDataPublicKey}, logger,
+			WithWebhookWindow(5*time.Minute),
+			WithClock(func() time.Time { return now }),
+		)
+		_, err := client.VerifyAndParseWebhookRequest(newSignedWebhookRequest(t, testOrderWebhookBody))
+		if tc.valid {
+			assert.Nil(t, err, err)
+		} else {
+			assert.ErrorIs(t, err, ErrWebhookExpired)
+		}
+	}
+}
+
+func TestWebhookNonceReplay(t *testing.T) {
+	client := NewMerchant("", "", fixedPublicKeyTestCache{exist: true, publicKey: testDataPublicKey}, logger,
+		WithWebhookNonceStore(NewCacheNonceStore(NewMemoryCache(0))),
+	)
+
+	// a forged request must not burn the nonce
+	forged := newSignedWebhookRequest(t, testOrderWebhookBody)
+	forged.Header.Set("BinancePay-Signature", "AAAA")
+	_, err := client.VerifyAndParseWebhookRequest(forged)
+	assert.NotNil(t, err)
+	assert.NotErrorIs(t, err, ErrWebhookReplayed)
+
+	_, err = client.VerifyAndParseWebhookRequest(newSignedWebhookRequest(t, testOrderWebhookBody))
+	assert.Nil(t, err, err)
+
+	_, err = client.VerifyAndParseWebhookRequest(newSignedWebhookRequest(t, testOrderWebhookBody))
+	assert.ErrorIs(t, err, ErrWebhookInProgress)
+
+	// replays are retried until the first delivery was processed
+	calls := 0
+	handler := NewWebhookHandler(client).OnOrder(func(ctx context.Context, noti *OrderNoti, bizStatus BizStatus) error {
+		calls++
+		return nil
+	})
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, newSignedWebhookRequest(t, testOrderWebhookBody))
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.Equal(t, 0, calls)
+
+	// then acknowledged, so binance pay stops delivering them
+	assert.Nil(t, client.CompleteWebhookNonce(context.Background(), "abc"))
+	_, err = client.VerifyAndParseWebhookRequest(newSignedWebhookRequest(t, testOrderWebhookBody))
+	assert.ErrorIs(t, err, ErrWebhookReplayed)
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, newSignedWebhookRequest(t, testOrderWebhookBody))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, `{"returnCode":"SUCCESS","returnMessage":null}`, w.Body.String())
+	assert.Equal(t, 0, calls)
+}
+
+func TestWebhookNonceReplayedWhileProcessing(t *testing.T) {
+	client := NewMerchant("", "", fixedPublicKeyTestCache{exist: true, publicKey: testDataPublicKey}, logger,
+		WithWebhookNonceStore(NewCacheNonceStore(NewMemoryCache(0))),
+	)
+	processing := make(chan struct{})
+	fail := make(chan struct{})
+	var calls int32
+	handler := NewWebhookHandler(client).OnOrder(func(ctx context.Context, noti *OrderNoti, bizStatus BizStatus) error {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			close(processing)
+			<-fail
+			return errors.New("database unavailable")
+		}
+		return nil
+	})
+
+	first := httptest.NewRecorder()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		handler.ServeHTTP(first, newSignedWebhookRequest(t, testOrderWebhookBody))
+	}()
+	<-processing
+
+	// the replay must not be acknowledged while the first delivery may still fail
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, newSignedWebhookRequest(t, testOrderWebhookBody))
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.Contains(t, w.Body.String(), `"returnCode":"FAIL"`)
+
+	close(fail)
+	<-done
+	assert.Equal(t, http.StatusInternalServerError, first.Code)
+
+	// the redelivery is processed
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, newSignedWebhookRequest(t, testOrderWebhookBody))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+}
+
+func TestWebhookNonceReleasedOnCallbackError(t *testing.T) {
+	client := NewMerchant("", "", fixedPublicKeyTestCache{exist: true, publicKey: testDataPublicKey}, logger,
+		WithWebhookNonceStore(NewCacheNonceStore(NewMemoryCache(0))),
+	)
+	var calls int
+	handler := NewWebhookHandler(client).OnOrder(func(ctx context.Context, noti *OrderNoti, bizStatus BizStatus) error {
+		calls++
+		if calls == 1 {
+			return errors.New("database unavailable")
+		}
+		return nil
+	})
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, newSignedWebhookRequest(t, testOrderWebhookBody))
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+
+	// the redelivery with the same nonce is processed
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, newSignedWebhookRequest(t, testOrderWebhookBody))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, 2, calls)
+
+	// once processed, it's a replay
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, newSignedWebhookRequest(t, testOrderWebhookBody))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, 2, calls)
+}
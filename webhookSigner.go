@@ -0,0 +1,148 @@
+package binancepay
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+type WebhookSignerOption func(s *WebhookSigner)
+
+// WithSignerClock overrides time.Now used for the Binancepay-Timestamp header.
+func WithSignerClock(now func() time.Time) WebhookSignerOption {
+	return func(s *WebhookSigner) {
+		s.now = now
+	}
+}
+
+// WithSignerNonceFunc overrides Nonce used for the Binancepay-Nonce header.
+func WithSignerNonceFunc(nonce func() string) WebhookSignerOption {
+	return func(s *WebhookSigner) {
+		s.nonce = nonce
+	}
+}
+
+// WebhookSigner signs webhook requests the way binance pay does, for tests and local tooling.
+// Feed Certificate into the Cache of a Merchant to have its webhooks accepted.
+type WebhookSigner struct {
+	privateKey *rsa.PrivateKey
+	serial     string
+	now        func() time.Time
+	nonce      func() string
+}
+
+func NewWebhookSigner(privateKey *rsa.PrivateKey, serial string, opts ...WebhookSignerOption) *WebhookSigner {
+	s := &WebhookSigner{
+		privateKey: privateKey,
+		serial:     serial,
+		now:        time.Now,
+		nonce:      Nonce,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// ParsePrivateKey parses a PEM encoded PKCS1 or PKCS8 RSA private key.
+func ParsePrivateKey(privateKey string) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode([]byte(privateKey))
+	if block == nil {
+		return nil, fmt.Errorf("decodePrivatePEM(): block is nil")
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("x509.ParsePKCS8PrivateKey(): %w", err)
+	}
+	rsaKey, ok := key.(*rsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("not a RSA private key: %T", key)
+	}
+	return rsaKey, nil
+}
+
+// Certificate returns the certificate matching the private key of the signer,
+// in the shape returned by QueryCertificateRequest.
+func (s *WebhookSigner) Certificate() (Certificate, error) {
+	der, err := x509.MarshalPKIXPublicKey(&s.privateKey.PublicKey)
+	if err != nil {
+		return Certificate{}, fmt.Errorf("x509.MarshalPKIXPublicKey(): %w", err)
+	}
+	return Certificate{
+		CertSerial: s.serial,
+		CertPublic: string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})),
+	}, nil
+}
+
+// Sign returns the base64 encoded SHA256 RSA signature of payload, see BuildPayload.
+func (s *WebhookSigner) Sign(payload []byte) (string, error) {
+	digest := sha256.Sum256(payload)
+	signature, err := rsa.SignPKCS1v15(rand.Reader, s.privateKey, crypto.SHA256, digest[:])
+	if err != nil {
+		return "", fmt.Errorf("rsa.SignPKCS1v15(): %w", err)
+	}
+	return base64.StdEncoding.EncodeToString(signature), nil
+}
+
+// NewRequest builds a signed webhook request posting data, JSON encoded, to url.
+func (s *WebhookSigner) NewRequest(url string, bizType NotiBizType, bizId string, bizStatus BizStatus, data interface{}) (*http.Request, error) {
+	rawData, err := json.Marshal(data)
+	if err != nil {
+		return nil, fmt.Errorf("json.Marshal(data): %w", err)
+	}
+	body, err := json.Marshal(webhookRawReq{
+		BizType:   bizType,
+		BizId:     json.Number(bizId),
+		RawData:   string(rawData),
+		BizStatus: bizStatus,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("json.Marshal(body): %w", err)
+	}
+
+	timestamp := strconv.FormatInt(s.now().UnixMilli(), 10)
+	nonce := s.nonce()
+	signature, err := s.Sign([]byte(BuildPayload(string(body), timestamp, nonce)))
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("http.NewRequest(): %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Binancepay-Timestamp", timestamp)
+	req.Header.Set("Binancepay-Nonce", nonce)
+	req.Header.Set("Binancepay-Signature", signature)
+	req.Header.Set("BinancePay-Certificate-SN", s.serial)
+	return req, nil
+}
+
+// NewOrderRequest builds a signed NotiBizTypeOrder webhook request, bizStatus is e.g. "PAY_SUCCESS" or "PAY_CLOSED".
+func (s *WebhookSigner) NewOrderRequest(url string, bizId string, bizStatus BizStatus, noti *OrderNoti) (*http.Request, error) {
+	return s.NewRequest(url, NotiBizTypeOrder, bizId, bizStatus, noti)
+}
+
+// NewRefundRequest builds a signed NotiBizTypePayRefund webhook request, bizStatus is e.g. "REFUND_SUCCESS" or "REFUND_REJECTED".
+func (s *WebhookSigner) NewRefundRequest(url string, bizId string, bizStatus BizStatus, noti *RefundOrderNoti) (*http.Request, error) {
+	return s.NewRequest(url, NotiBizTypePayRefund, bizId, bizStatus, noti)
+}
+
+// NewPayoutRequest builds a signed NotiBizTypePayout webhook request.
+func (s *WebhookSigner) NewPayoutRequest(url string, bizId string, bizStatus BizStatus, noti *PayoutNoti) (*http.Request, error) {
+	return s.NewRequest(url, NotiBizTypePayout, bizId, bizStatus, noti)
+}
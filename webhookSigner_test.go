@@ -0,0 +1,75 @@
+package binancepay
+
+import (
+	"context"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestWebhookSigner(t *testing.T) {
+	privateKey, err := ParsePrivateKey(testDataPrivateKey)
+	assert.Nil(t, err, err)
+	now := time.UnixMilli(1654943252000)
+	signer := NewWebhookSigner(privateKey, "serial-1",
+		WithSignerClock(func() time.Time { return now }),
+		WithSignerNonceFunc(func() string { return "abc" }),
+	)
+
+	cert, err := signer.Certificate()
+	assert.Nil(t, err, err)
+	assert.Equal(t, "serial-1", cert.CertSerial)
+	pub, err := ParsePublicKey(cert.CertPublic)
+	assert.Nil(t, err, err)
+	assert.Equal(t, &privateKey.PublicKey, pub)
+
+	cache := NewMemoryCache(0)
+	client := NewMerchant("", "", cache, logger, WithClock(func() time.Time { return now }), WithWebhookWindow(time.Minute))
+	assert.Nil(t, cache.SetJSON(context.Background(), client.certCacheKey(), []Certificate{cert}, time.Hour))
+
+	orderReq, err := signer.NewOrderRequest("/webhook", "29383937493038367292", "PAY_SUCCESS", &OrderNoti{
+		MerchantTradeNo: "9825382937292",
+		TotalFee:        decimal.RequireFromString("0.88"),
+		Currency:        "USDT",
+	})
+	assert.Nil(t, err, err)
+	assert.Equal(t, "1654943252000", orderReq.Header.Get("Binancepay-Timestamp"))
+	assert.Equal(t, "abc", orderReq.Header.Get("Binancepay-Nonce"))
+	assert.Equal(t, "serial-1", orderReq.Header.Get("BinancePay-Certificate-SN"))
+
+	rawReq, err := client.VerifyAndParseWebhookRequest(orderReq)
+	assert.Nil(t, err, err)
+	assert.Equal(t, NotiBizTypeOrder, rawReq.BizType)
+	assert.Equal(t, "29383937493038367292", rawReq.BizId.String())
+	assert.Equal(t, BizStatusPaySuccess, rawReq.BizStatus)
+
+	refundReq, err := signer.NewRefundRequest("/webhook", "1", "REFUND_SUCCESS", &RefundOrderNoti{
+		MerchantTradeNo: "9825382937292",
+		RefundInfo:      RefundInfo{RefundRequestId: "68711039982968832", RefundAmount: decimal.RequireFromString("0.5")},
+	})
+	assert.Nil(t, err, err)
+	var refundNoti *RefundOrderNoti
+	handler := NewWebhookHandler(client).OnRefund(func(ctx context.Context, noti *RefundOrderNoti, bizStatus BizStatus) error {
+		refundNoti = noti
+		return nil
+	})
+	rawReq, err = client.VerifyAndParseWebhookRequest(refundReq)
+	assert.Nil(t, err, err)
+	_, err = handler.dispatch(context.Background(), rawReq)
+	assert.Nil(t, err, err)
+	assert.Equal(t, "68711039982968832", refundNoti.RefundInfo.RefundRequestId)
+	assert.Equal(t, "0.5", refundNoti.RefundInfo.RefundAmount.String())
+
+	payoutReq, err := signer.NewPayoutRequest("/webhook", "2", "SUCCESS", &PayoutNoti{RequestId: "payout20261018"})
+	assert.Nil(t, err, err)
+	_, err = client.VerifyAndParseWebhookRequest(payoutReq)
+	assert.Nil(t, err, err)
+}
+
+func TestParsePrivateKeyInvalid(t *testing.T) {
+	_, err := ParsePrivateKey("not a key")
+	assert.NotNil(t, err)
+	_, err = ParsePrivateKey(testDataPublicKey)
+	assert.NotNil(t, err)
+}